@@ -6,9 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/xman12/go-composer/pkg/autoload"
 	"github.com/xman12/go-composer/pkg/composer"
-	"github.com/xman12/go-composer/pkg/installer"
 	"github.com/xman12/go-composer/pkg/scripts"
 )
 
@@ -17,6 +15,11 @@ var (
 	noAutoload   bool
 	newLock      bool
 	forceNewLock bool
+	offline      bool
+	noCache      bool
+	jobs         int
+	preferSource bool
+	preferDist   bool
 )
 
 var installCmd = &cobra.Command{
@@ -32,6 +35,12 @@ func init() {
 	installCmd.Flags().BoolVar(&noAutoload, "no-autoloader", false, "skip autoloader generation")
 	installCmd.Flags().BoolVar(&newLock, "new-lock", true, "create go-composer.lock file")
 	installCmd.Flags().BoolVar(&forceNewLock, "force-new-lock", false, "force new go-composer.lock file")
+	installCmd.Flags().BoolVar(&noCache, "no-cache", false, "do not read or write the package cache (or COMPOSER_NO_CACHE=1)")
+	installCmd.Flags().IntVar(&jobs, "jobs", 0, "maximum parallel downloads (default COMPOSER_MAX_PARALLEL_HTTP or 12)")
+	installCmd.Flags().BoolVar(&offline, "offline", false, "install only from the local cache (or COMPOSER_OFFLINE=1)")
+	installCmd.Flags().BoolVar(&preferSource, "prefer-source", false, "install packages from source (git clone) when available")
+	installCmd.Flags().BoolVar(&preferDist, "prefer-dist", false, "install packages from dist archives (default)")
+	addAutoloaderFlags(installCmd)
 	rootCmd.AddCommand(installCmd)
 }
 
@@ -47,7 +56,6 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	composerLockPathFile := "composer.lock"
 	composerLockGoPathFile := "go-composer.lock"
 	composerLock := ""
-	vendorDir := "vendor"
 
 	// Проверяем наличие composer.json
 	if _, err := os.Stat(composerJSONPath); os.IsNotExist(err) {
@@ -62,6 +70,7 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load composer.json: %w", err)
 	}
+	config := composer.LoadConfig(composerJSON)
 
 	// Создаем executor для скриптов
 	projectRoot, _ := filepath.Abs(".")
@@ -73,7 +82,10 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Создаем installer
-	inst := installer.NewInstaller(vendorDir)
+	inst, err := newInstaller(config)
+	if err != nil {
+		return err
+	}
 
 	var lock *composer.ComposerLock
 
@@ -103,7 +115,7 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		}
 
 		// Устанавливаем напрямую из lock без resolve через Packagist
-		if err := inst.InstallFromLock(lock, !noDev); err != nil {
+		if err := inst.InstallFromLock(lock, composerJSON, !noDev); err != nil {
 			return fmt.Errorf("failed to install packages: %w", err)
 		}
 	} else {
@@ -140,8 +152,8 @@ func runInstall(cmd *cobra.Command, args []string) error {
 			fmt.Printf("⚠️  Warning: pre-autoload-dump failed: %v\n", err)
 		}
 
-		gen := autoload.NewGenerator(vendorDir)
-		if err := gen.Generate(lock, composerJSON); err != nil {
+		gen := newGenerator(config)
+		if err := gen.Generate(lock, composerJSON, !noDev); err != nil {
 			return fmt.Errorf("failed to generate autoload: %w", err)
 		}
 
@@ -6,9 +6,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/xman12/go-composer/pkg/autoload"
 	"github.com/xman12/go-composer/pkg/composer"
-	"github.com/xman12/go-composer/pkg/installer"
 )
 
 var (
@@ -27,6 +25,7 @@ Usage: go-composer require vendor/package:^1.0`,
 func init() {
 	requireCmd.Flags().BoolVar(&requireDev, "dev", false, "add to require-dev")
 	requireCmd.Flags().BoolVar(&noAutoload, "no-autoloader", false, "skip autoloader generation")
+	addAutoloaderFlags(requireCmd)
 	rootCmd.AddCommand(requireCmd)
 }
 
@@ -42,7 +41,6 @@ func runRequire(cmd *cobra.Command, args []string) error {
 	composerLockPathFile := "composer.lock"
 	composerLockGoPathFile := "go-composer.lock"
 	composerLock := ""
-	vendorDir := "vendor"
 
 	fmt.Println("🚀 go-composer - Adding packages")
 	fmt.Println()
@@ -63,6 +61,8 @@ func runRequire(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	config := composer.LoadConfig(composerJSON)
+
 	// Инициализируем map'ы если nil
 	if composerJSON.Require == nil {
 		composerJSON.Require = make(map[string]string)
@@ -97,7 +97,10 @@ func runRequire(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Создаем installer
-	inst := installer.NewInstaller(vendorDir)
+	inst, err := newInstaller(config)
+	if err != nil {
+		return err
+	}
 
 	// Устанавливаем зависимости
 	lock, err := inst.Install(composerJSON, true)
@@ -118,8 +121,8 @@ func runRequire(cmd *cobra.Command, args []string) error {
 
 	// Генерируем autoload
 	if !noAutoload {
-		gen := autoload.NewGenerator(vendorDir)
-		if err := gen.Generate(lock, composerJSON); err != nil {
+		gen := newGenerator(config)
+		if err := gen.Generate(lock, composerJSON, true); err != nil {
 			return fmt.Errorf("failed to generate autoload: %w", err)
 		}
 	}
@@ -1,15 +1,30 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/xman12/go-composer/pkg/auth"
+	"github.com/xman12/go-composer/pkg/autoload"
+	"github.com/xman12/go-composer/pkg/cache"
+	"github.com/xman12/go-composer/pkg/composer"
+	"github.com/xman12/go-composer/pkg/installer"
+	"github.com/xman12/go-composer/pkg/packagist"
+	"github.com/xman12/go-composer/pkg/vcs"
 )
 
 var (
-	verbose bool
-	workDir string
+	verbose       bool
+	workDir       string
+	noInteraction bool
+
+	optimizeAutoloader    bool
+	classmapAuthoritative bool
+	apcuAutoloader        bool
+	apcuAutoloaderPrefix  string
 )
 
 var rootCmd = &cobra.Command{
@@ -30,12 +45,123 @@ Copyright (c) 2025 Aleksandr Belyshev`,
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		if hint := errorHint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, "💡 "+hint)
+		}
 		os.Exit(1)
 	}
 }
 
+// errorHint подсказывает, что делать с ошибкой установки
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, installer.ErrLocalChanges):
+		return "Review them with \"go-composer status -v\", revert them, or set config.discard-changes to true or \"stash\"."
+	case errors.Is(err, packagist.ErrNotCached), errors.Is(err, vcs.ErrNoMirror):
+		return "The package is not in the local cache. Run the command once without --offline to populate it."
+	case errors.Is(err, packagist.ErrNotFound):
+		return "The package or file does not exist. Check the package name and version constraint."
+	case errors.Is(err, packagist.ErrAuth):
+		return "The repository requires credentials. Add them to auth.json (project or COMPOSER_HOME) or COMPOSER_AUTH."
+	case errors.Is(err, packagist.ErrTransient):
+		return "The repository is temporarily unavailable. Try again later, raise COMPOSER_HTTP_RETRIES/COMPOSER_HTTP_TIMEOUT, or use --offline with a warm cache."
+	}
+	return ""
+}
+
+// newInstaller создает installer с учетом флагов, config из composer.json и переменных окружения:
+// vendor-dir, bin-dir, cache-*-dir, учетные данные (auth.json, COMPOSER_AUTH), --jobs,
+// --no-cache, --offline, --prefer-source/--prefer-dist (приоритетнее config.preferred-install)
+func newInstaller(config composer.Config) (*installer.Installer, error) {
+	inst := installer.NewInstaller(config.VendorDir)
+
+	store, err := auth.Load(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	store.SetGitHubDomains(config.GitHubDomains)
+	inst.SetAuth(store)
+
+	inst.SetJobs(jobs)
+	switch {
+	case noCache || envBool("COMPOSER_NO_CACHE"):
+		inst.SetCache(nil)
+	case config.CacheDir != "" || config.CacheFilesDir != "" || config.CacheRepoDir != "" || config.CacheVcsDir != "":
+		root := config.CacheDir
+		if root == "" {
+			root = cache.DefaultDir()
+		}
+		inst.SetCache(cache.NewDirs(root, config.CacheFilesDir, config.CacheRepoDir, config.CacheVcsDir))
+	}
+	if offline || envBool("COMPOSER_OFFLINE") {
+		fmt.Println("📴 Offline mode: using only cached metadata and archives")
+		inst.SetOffline(true)
+	}
+
+	switch {
+	case preferSource && preferDist:
+		return nil, fmt.Errorf("--prefer-source and --prefer-dist cannot be used together")
+	case preferSource:
+		inst.SetPreferredInstall(composer.PreferredInstall{Default: composer.InstallSource})
+	case preferDist:
+		inst.SetPreferredInstall(composer.PreferredInstall{Default: composer.InstallDist})
+	default:
+		inst.SetPreferredInstall(config.PreferredInstall)
+	}
+
+	inst.SetDiscardChanges(config.DiscardChanges)
+	inst.SetInteractive(!noInteraction && isTerminal(os.Stdin))
+
+	inst.SetBinDir(config.BinDir)
+	switch config.BinCompat {
+	case installer.BinCompatAuto, installer.BinCompatProxy, installer.BinCompatFull, installer.BinCompatSymlink:
+		inst.SetBinCompat(config.BinCompat)
+	case "":
+	default:
+		fmt.Printf("⚠️  Unknown config.bin-compat %q, using %q\n", config.BinCompat, installer.BinCompatAuto)
+	}
+
+	return inst, nil
+}
+
+// newGenerator создает генератор autoload с учетом флагов -o, --classmap-authoritative,
+// --apcu-autoloader и config.optimize-autoloader/classmap-authoritative/apcu-autoloader
+func newGenerator(config composer.Config) *autoload.Generator {
+	gen := autoload.NewGenerator(".", config.VendorDir)
+
+	gen.SetOptimize(optimizeAutoloader || config.OptimizeAutoloader)
+	gen.SetClassMapAuthoritative(classmapAuthoritative || config.ClassmapAuthoritative)
+	gen.SetApcu(apcuAutoloader || apcuAutoloaderPrefix != "" || config.ApcuAutoloader, apcuAutoloaderPrefix)
+
+	return gen
+}
+
+// addAutoloaderFlags добавляет команде флаги оптимизации autoload
+func addAutoloaderFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&optimizeAutoloader, "optimize-autoloader", "o", false, "convert PSR-0/4 autoloading to a classmap for a faster autoloader")
+	cmd.Flags().BoolVarP(&classmapAuthoritative, "classmap-authoritative", "a", false, "autoload classes from the classmap only (implies --optimize-autoloader)")
+	cmd.Flags().BoolVar(&apcuAutoloader, "apcu-autoloader", false, "use APCu to cache found/not-found classes")
+	cmd.Flags().StringVar(&apcuAutoloaderPrefix, "apcu-autoloader-prefix", "", "custom prefix for the APCu autoloader cache (implies --apcu-autoloader)")
+}
+
+// isTerminal проверяет, что файл - интерактивный терминал
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// envBool читает булеву переменную окружения (1, true, yes, on)
+func envBool(name string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(name))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVarP(&workDir, "working-dir", "d", ".", "working directory")
+	rootCmd.PersistentFlags().BoolVarP(&noInteraction, "no-interaction", "n", false, "do not ask any interactive question")
 }
 
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xman12/go-composer/pkg/composer"
+	"github.com/xman12/go-composer/pkg/installer"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show locally modified packages",
+	Long: `Compares installed packages in vendor/ with the files they were installed with
+and lists packages that were modified locally. Use -v to see the changed files.`,
+	RunE:         runStatus,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	// Меняем рабочую директорию если указано
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return fmt.Errorf("failed to change directory: %w", err)
+		}
+	}
+
+	// vendor-dir берется из config composer.json (если он есть)
+	var composerJSON *composer.ComposerJSON
+	if _, err := os.Stat("composer.json"); err == nil {
+		if composerJSON, err = composer.LoadComposerJSON("composer.json"); err != nil {
+			return fmt.Errorf("failed to load composer.json: %w", err)
+		}
+	}
+	vendorDir := composer.LoadConfig(composerJSON).VendorDir
+
+	composerLock := ""
+	if _, err := os.Stat("composer.lock"); err == nil {
+		composerLock = "composer.lock"
+	} else if _, err := os.Stat("go-composer.lock"); err == nil {
+		composerLock = "go-composer.lock"
+	} else {
+		return fmt.Errorf("no lock file found, run install first")
+	}
+
+	lock, err := composer.LoadComposerLock(composerLock)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", composerLock, err)
+	}
+
+	packages := append(append([]composer.LockedPackage{}, lock.Packages...), lock.PackagesDev...)
+	paths := composer.NewInstallPaths(composerJSON, packages)
+
+	var modified []string
+	for _, pkg := range packages {
+		changes, tracked, err := installer.LocalChanges(vendorDir, pkg.Name)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", pkg.Name, err)
+		}
+		if !tracked {
+			if verbose {
+				fmt.Printf("  ℹ️  %s is not tracked (symlinked, missing or installed without a manifest)\n", pkg.Name)
+			}
+			continue
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		if len(modified) == 0 {
+			fmt.Println("⚠️  You have changes in the following dependencies:")
+		}
+		modified = append(modified, pkg.Name)
+
+		fmt.Printf("  %s\n", filepath.ToSlash(paths.Dir(vendorDir, pkg)))
+		if verbose {
+			for _, change := range changes {
+				fmt.Printf("      %s %s\n", change.Kind, change.Path)
+			}
+		}
+	}
+
+	if len(modified) == 0 {
+		fmt.Println("✅ No local changes")
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", installer.ErrLocalChanges, strings.Join(modified, ", "))
+}
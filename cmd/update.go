@@ -5,9 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/xman12/go-composer/pkg/autoload"
 	"github.com/xman12/go-composer/pkg/composer"
-	"github.com/xman12/go-composer/pkg/installer"
 )
 
 var updateCmd = &cobra.Command{
@@ -21,6 +19,12 @@ composer.json constraints and updates composer.lock file.`,
 func init() {
 	updateCmd.Flags().BoolVar(&noDev, "no-dev", false, "skip dev dependencies")
 	updateCmd.Flags().BoolVar(&noAutoload, "no-autoloader", false, "skip autoloader generation")
+	updateCmd.Flags().BoolVar(&noCache, "no-cache", false, "do not read or write the package cache (or COMPOSER_NO_CACHE=1)")
+	updateCmd.Flags().IntVar(&jobs, "jobs", 0, "maximum parallel downloads (default COMPOSER_MAX_PARALLEL_HTTP or 12)")
+	updateCmd.Flags().BoolVar(&offline, "offline", false, "resolve and install only from the local cache (or COMPOSER_OFFLINE=1)")
+	updateCmd.Flags().BoolVar(&preferSource, "prefer-source", false, "install packages from source (git clone) when available")
+	updateCmd.Flags().BoolVar(&preferDist, "prefer-dist", false, "install packages from dist archives (default)")
+	addAutoloaderFlags(updateCmd)
 	rootCmd.AddCommand(updateCmd)
 }
 
@@ -36,7 +40,6 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	composerLockPathFile := "composer.lock"
 	composerLockGoPathFile := "go-composer.lock"
 	composerLock := ""
-	vendorDir := "vendor"
 
 	// Проверяем наличие composer.json
 	if _, err := os.Stat(composerJSONPath); os.IsNotExist(err) {
@@ -51,9 +54,13 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load composer.json: %w", err)
 	}
+	config := composer.LoadConfig(composerJSON)
 
 	// Создаем installer
-	inst := installer.NewInstaller(vendorDir)
+	inst, err := newInstaller(config)
+	if err != nil {
+		return err
+	}
 
 	// Разрешаем и устанавливаем зависимости
 	lock, err := inst.Install(composerJSON, !noDev)
@@ -75,8 +82,8 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 
 	// Генерируем autoload
 	if !noAutoload {
-		gen := autoload.NewGenerator(vendorDir)
-		if err := gen.Generate(lock, composerJSON); err != nil {
+		gen := newGenerator(config)
+		if err := gen.Generate(lock, composerJSON, !noDev); err != nil {
 			return fmt.Errorf("failed to generate autoload: %w", err)
 		}
 	}
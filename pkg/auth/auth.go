@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xman12/go-composer/pkg/composer"
+)
+
+// Config представляет содержимое auth.json
+type Config struct {
+	HTTPBasic      map[string]BasicCredentials     `json:"http-basic,omitempty"`
+	Bearer         map[string]string               `json:"bearer,omitempty"`
+	GitHubOAuth    map[string]string               `json:"github-oauth,omitempty"`
+	GitLabToken    map[string]GitLabToken          `json:"gitlab-token,omitempty"`
+	GitLabOAuth    map[string]string               `json:"gitlab-oauth,omitempty"`
+	BitbucketOAuth map[string]BitbucketCredentials `json:"bitbucket-oauth,omitempty"`
+}
+
+// BasicCredentials - логин и пароль для http-basic
+type BasicCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// GitLabToken - персональный токен GitLab (строка или объект с username)
+type GitLabToken struct {
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token"`
+}
+
+// UnmarshalJSON позволяет задавать gitlab-token строкой или объектом
+func (t *GitLabToken) UnmarshalJSON(data []byte) error {
+	var token string
+	if err := json.Unmarshal(data, &token); err == nil {
+		*t = GitLabToken{Token: token}
+		return nil
+	}
+
+	type plain GitLabToken
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*t = GitLabToken(obj)
+	return nil
+}
+
+// BitbucketCredentials - OAuth consumer для Bitbucket
+type BitbucketCredentials struct {
+	ConsumerKey    string `json:"consumer-key"`
+	ConsumerSecret string `json:"consumer-secret"`
+}
+
+// bitbucketTokenURL - адрес обмена OAuth consumer на access token
+var bitbucketTokenURL = "https://bitbucket.org/site/oauth2/access_token"
+
+// Store хранит учетные данные и добавляет их к запросам по имени хоста
+type Store struct {
+	config        Config
+	githubDomains map[string]bool // config.github-domains (GitHub Enterprise)
+
+	mu              sync.Mutex
+	bitbucketTokens map[string]string
+}
+
+// NewStore создает хранилище из готовой конфигурации
+func NewStore(config Config) *Store {
+	return &Store{
+		config:          config,
+		bitbucketTokens: make(map[string]string),
+	}
+}
+
+// SetGitHubDomains задает домены GitHub Enterprise из config.github-domains:
+// для них токен github-oauth передается и на API хост api.<домен>
+func (s *Store) SetGitHubDomains(domains []string) {
+	s.githubDomains = make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		s.githubDomains[strings.ToLower(domain)] = true
+	}
+}
+
+// Load собирает учетные данные в порядке приоритета (последний побеждает):
+// COMPOSER_HOME/auth.json, auth.json проекта, переменная окружения COMPOSER_AUTH
+func Load(projectDir string) (*Store, error) {
+	config := Config{}
+
+	paths := []string{filepath.Join(projectDir, "auth.json")}
+	if home := composer.HomeDir(); home != "" {
+		paths = append([]string{filepath.Join(home, "auth.json")}, paths...)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if err := mergeJSON(&config, data); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", path, err)
+		}
+	}
+
+	if env := os.Getenv("COMPOSER_AUTH"); env != "" {
+		if err := mergeJSON(&config, []byte(env)); err != nil {
+			return nil, fmt.Errorf("invalid COMPOSER_AUTH: %w", err)
+		}
+	}
+
+	return NewStore(config), nil
+}
+
+// mergeJSON добавляет учетные данные из JSON поверх существующих
+func mergeJSON(config *Config, data []byte) error {
+	var next Config
+	if err := json.Unmarshal(data, &next); err != nil {
+		return err
+	}
+
+	config.HTTPBasic = mergeMap(config.HTTPBasic, next.HTTPBasic)
+	config.Bearer = mergeMap(config.Bearer, next.Bearer)
+	config.GitHubOAuth = mergeMap(config.GitHubOAuth, next.GitHubOAuth)
+	config.GitLabToken = mergeMap(config.GitLabToken, next.GitLabToken)
+	config.GitLabOAuth = mergeMap(config.GitLabOAuth, next.GitLabOAuth)
+	config.BitbucketOAuth = mergeMap(config.BitbucketOAuth, next.BitbucketOAuth)
+	return nil
+}
+
+// mergeMap копирует значения src поверх dst
+func mergeMap[V any](dst, src map[string]V) map[string]V {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]V, len(src))
+	}
+	for key, value := range src {
+		dst[key] = value
+	}
+	return dst
+}
+
+// Apply добавляет к запросу учетные данные для его хоста.
+// Ключи auth.json сравниваются с "host:port" и с именем хоста.
+func (s *Store) Apply(req *http.Request) error {
+	if s == nil {
+		return nil
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	hostPort := strings.ToLower(req.URL.Host)
+
+	if creds, ok := lookup(s.config.HTTPBasic, hostPort, host); ok {
+		req.SetBasicAuth(creds.Username, creds.Password)
+		return nil
+	}
+
+	if token, ok := lookup(s.config.Bearer, hostPort, host); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	if token, ok := lookup(s.config.GitHubOAuth, s.githubDomain(host)); ok {
+		req.Header.Set("Authorization", "token "+token)
+		return nil
+	}
+
+	if token, ok := lookup(s.config.GitLabOAuth, hostPort, host); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	if token, ok := lookup(s.config.GitLabToken, hostPort, host); ok {
+		if token.Username != "" {
+			req.SetBasicAuth(token.Username, token.Token)
+		} else {
+			req.Header.Set("PRIVATE-TOKEN", token.Token)
+		}
+		return nil
+	}
+
+	if domain := bitbucketDomain(host); domain != "" {
+		if creds, ok := s.config.BitbucketOAuth[domain]; ok {
+			token, err := s.bitbucketToken(domain, creds)
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return nil
+}
+
+// CheckRedirect используется как http.Client.CheckRedirect: при переходе
+// на другой хост (или с HTTPS на HTTP) удаляет учетные данные исходного хоста
+// и добавляет учетные данные нового, если они настроены.
+func (s *Store) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+
+	prev := via[len(via)-1]
+	sameHost := strings.EqualFold(req.URL.Host, prev.URL.Host)
+	downgrade := prev.URL.Scheme == "https" && req.URL.Scheme != "https"
+
+	if !sameHost || downgrade {
+		StripCredentials(req.Header)
+		if downgrade {
+			return nil
+		}
+	}
+
+	return s.Apply(req)
+}
+
+// StripCredentials удаляет заголовки авторизации из запроса
+func StripCredentials(header http.Header) {
+	header.Del("Authorization")
+	header.Del("PRIVATE-TOKEN")
+}
+
+// lookup ищет значение по одному из ключей хоста
+func lookup[V any](values map[string]V, keys ...string) (V, bool) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if value, ok := values[key]; ok {
+			return value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// githubDomain сопоставляет служебные хосты GitHub с доменом из auth.json.
+// Префикс api. снимается только у доменов GitHub Enterprise из config.github-domains,
+// иначе токен для example.com уходил бы и на api.example.com.
+func (s *Store) githubDomain(host string) string {
+	switch host {
+	case "api.github.com", "codeload.github.com":
+		return "github.com"
+	}
+	if domain := strings.TrimPrefix(host, "api."); domain != host && s.githubDomains[domain] {
+		// GitHub Enterprise: api.github.example.com -> github.example.com
+		return domain
+	}
+	return host
+}
+
+// bitbucketDomain сопоставляет хосты Bitbucket с доменом из auth.json
+func bitbucketDomain(host string) string {
+	switch host {
+	case "bitbucket.org", "api.bitbucket.org":
+		return "bitbucket.org"
+	}
+	return ""
+}
+
+// bitbucketToken обменивает OAuth consumer на access token (client credentials)
+func (s *Store) bitbucketToken(domain string, creds BitbucketCredentials) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := s.bitbucketTokens[domain]; ok {
+		return token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequest(http.MethodPost, bitbucketTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(creds.ConsumerKey, creds.ConsumerSecret)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain bitbucket access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to obtain bitbucket access token: HTTP %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse bitbucket access token: %w", err)
+	}
+
+	s.bitbucketTokens[domain] = token.AccessToken
+	return token.AccessToken, nil
+}
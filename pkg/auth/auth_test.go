@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	store := NewStore(Config{
+		HTTPBasic: map[string]BasicCredentials{"repo.example.com": {Username: "user", Password: "secret"}},
+		Bearer:    map[string]string{"cdn.example.com": "cdn-token"},
+		GitLabToken: map[string]GitLabToken{
+			"gitlab.example.com": {Token: "gitlab-token"},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		header  http.Header
+		want    string // ожидаемый заголовок Authorization
+		private string // ожидаемый заголовок PRIVATE-TOKEN
+	}{
+		{
+			name:   "same host keeps credentials",
+			from:   "https://repo.example.com/packages.json",
+			to:     "https://repo.example.com/p2/acme/lib.json",
+			header: http.Header{"Authorization": {"Basic dXNlcjpzZWNyZXQ="}},
+			want:   "Basic dXNlcjpzZWNyZXQ=",
+		},
+		{
+			name:   "other host without credentials",
+			from:   "https://repo.example.com/dist.zip",
+			to:     "https://evil.example.org/dist.zip",
+			header: http.Header{"Authorization": {"Basic dXNlcjpzZWNyZXQ="}},
+		},
+		{
+			name:   "other host with its own credentials",
+			from:   "https://repo.example.com/dist.zip",
+			to:     "https://cdn.example.com/dist.zip",
+			header: http.Header{"Authorization": {"Basic dXNlcjpzZWNyZXQ="}},
+			want:   "Bearer cdn-token",
+		},
+		{
+			name:   "private token is stripped",
+			from:   "https://gitlab.example.com/api/v4/archive.zip",
+			to:     "https://storage.example.net/archive.zip",
+			header: http.Header{"Private-Token": {"gitlab-token"}},
+		},
+		{
+			name:   "https to http downgrade on the same host",
+			from:   "https://repo.example.com/dist.zip",
+			to:     "http://repo.example.com/dist.zip",
+			header: http.Header{"Authorization": {"Basic dXNlcjpzZWNyZXQ="}},
+		},
+		{
+			name:   "other port is another host",
+			from:   "https://repo.example.com/dist.zip",
+			to:     "https://repo.example.com:8443/dist.zip",
+			header: http.Header{"Authorization": {"Bearer stale"}},
+			want:   "Basic dXNlcjpzZWNyZXQ=",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, _ := http.NewRequest(http.MethodGet, tt.from, nil)
+			req, _ := http.NewRequest(http.MethodGet, tt.to, nil)
+			req.Header = tt.header.Clone()
+
+			if err := store.CheckRedirect(req, []*http.Request{prev}); err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+			if got := req.Header.Get("PRIVATE-TOKEN"); got != tt.private {
+				t.Errorf("PRIVATE-TOKEN = %q, want %q", got, tt.private)
+			}
+		})
+	}
+}
+
+func TestCheckRedirectLimit(t *testing.T) {
+	via := make([]*http.Request, 10)
+	for i := range via {
+		via[i], _ = http.NewRequest(http.MethodGet, "https://repo.example.com/", nil)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://repo.example.com/", nil)
+
+	if err := NewStore(Config{}).CheckRedirect(req, via); err == nil {
+		t.Error("CheckRedirect followed more than 10 redirects")
+	}
+}
+
+func TestGitHubOAuthHosts(t *testing.T) {
+	store := NewStore(Config{GitHubOAuth: map[string]string{
+		"github.com":         "gh-token",
+		"example.com":        "example-token",
+		"github.example.org": "ghe-token",
+	}})
+	store.SetGitHubDomains([]string{"github.com", "github.example.org"})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/acme/lib", "token gh-token"},
+		{"https://api.github.com/repos/acme/lib/zipball/abc", "token gh-token"},
+		{"https://codeload.github.com/acme/lib/zip/abc", "token gh-token"},
+		{"https://example.com/archive.zip", "token example-token"},
+		{"https://api.example.com/archive.zip", ""},
+		{"https://github.example.org/api/v3/repos/acme/lib", "token ghe-token"},
+		{"https://api.github.example.org/repos/acme/lib", "token ghe-token"},
+		{"https://api.other.example.net/repos/acme/lib", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err := store.Apply(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package autoload
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// excludePattern преобразует путь exclude-from-classmap пакета из baseDir в регулярное
+// выражение, как Composer: "**" - любое число директорий, "*" - часть одного сегмента.
+// Под шаблон попадает сам путь и все, что внутри него.
+func excludePattern(baseDir, pattern string) string {
+	path := strings.Trim(filepath.ToSlash(pattern), "/")
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
+	}
+
+	path = regexp.QuoteMeta(path)
+	path = strings.ReplaceAll(path, `\*\*`, `.+?`)
+	path = strings.ReplaceAll(path, `\*`, `[^/]+?`)
+
+	return "^" + regexp.QuoteMeta(filepath.ToSlash(baseDir)) + "/" + path + "($|/)"
+}
+
+// excludeRegexp объединяет шаблоны exclude-from-classmap всех пакетов (nil - исключений нет)
+func excludeRegexp(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile("(?:" + strings.Join(patterns, "|") + ")")
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude-from-classmap pattern: %w", err)
+	}
+	return re, nil
+}
+
+// excluded проверяет, исключен ли путь из classmap
+func excluded(exclude *regexp.Regexp, path string) bool {
+	return exclude != nil && exclude.MatchString(filepath.ToSlash(path))
+}
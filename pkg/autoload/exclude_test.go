@@ -0,0 +1,48 @@
+package autoload
+
+import "testing"
+
+func TestExcludeFromClassmap(t *testing.T) {
+	const base = "/project/vendor/acme/lib"
+
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"no patterns", nil, base + "/src/A.php", false},
+		{"exact file", []string{"src/Legacy.php"}, base + "/src/Legacy.php", true},
+		{"file prefix is not a match", []string{"src/Legacy.php"}, base + "/src/Legacy.php.bak", false},
+		{"directory and its contents", []string{"tests/"}, base + "/tests/Unit/ATest.php", true},
+		{"directory itself", []string{"tests"}, base + "/tests", true},
+		{"directory name prefix is not a match", []string{"tests"}, base + "/tests-data/A.php", false},
+		{"other package", []string{"tests/"}, "/project/vendor/acme/other/tests/ATest.php", false},
+		{"leading slash and duplicate slashes", []string{"/src//Legacy/"}, base + "/src/Legacy/Old.php", true},
+		{"single star within a segment", []string{"src/*Test.php"}, base + "/src/FooTest.php", true},
+		{"single star does not cross directories", []string{"src/*Test.php"}, base + "/src/Unit/FooTest.php", false},
+		{"single star matches a directory", []string{"src/*/Fixtures"}, base + "/src/Unit/Fixtures/A.php", true},
+		{"double star crosses directories", []string{"src/**/Fixtures"}, base + "/src/Unit/Deep/Fixtures/A.php", true},
+		{"double star needs a directory", []string{"src/**/Fixtures"}, base + "/src/Fixtures/A.php", false},
+		{"regexp metacharacters are literal", []string{"src/a+b(c).php"}, base + "/src/a+b(c).php", true},
+		{"dot is literal", []string{"src/a.php"}, base + "/src/axphp", false},
+		{"any of several patterns", []string{"tests/", "src/Legacy"}, base + "/src/Legacy/A.php", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patterns []string
+			for _, pattern := range tt.patterns {
+				patterns = append(patterns, excludePattern(base, pattern))
+			}
+
+			exclude, err := excludeRegexp(patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := excluded(exclude, tt.path); got != tt.want {
+				t.Errorf("excluded(%q) = %v, want %v (patterns %q)", tt.path, got, tt.want, patterns)
+			}
+		})
+	}
+}
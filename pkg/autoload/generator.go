@@ -1,11 +1,16 @@
 package autoload
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/xman12/go-composer/pkg/composer"
@@ -13,71 +18,111 @@ import (
 
 // Generator генерирует autoload файлы
 type Generator struct {
-	vendorDir string
+	projectRoot string
+	vendorDir   string
+	paths       *composer.InstallPaths
+
+	optimize      bool   // -o: PSR-4/PSR-0 классы попадают в classmap
+	authoritative bool   // --classmap-authoritative: классы ищутся только в classmap
+	apcuPrefix    string // --apcu-autoloader: prefix кеша APCu, пусто - выключен
 }
 
-// NewGenerator создает новый генератор
-func NewGenerator(vendorDir string) *Generator {
-	// Приводим vendorDir к абсолютному пути
-	absVendorDir, err := filepath.Abs(vendorDir)
-	if err != nil {
-		// В случае ошибки используем как есть
-		absVendorDir = vendorDir
+// NewGenerator создает новый генератор для проекта в projectRoot.
+// vendorDir (config.vendor-dir) задается относительно projectRoot или абсолютным.
+func NewGenerator(projectRoot, vendorDir string) *Generator {
+	if !filepath.IsAbs(vendorDir) {
+		vendorDir = filepath.Join(projectRoot, vendorDir)
 	}
 
 	return &Generator{
-		vendorDir: absVendorDir,
+		projectRoot: absolute(projectRoot),
+		vendorDir:   absolute(vendorDir),
 	}
 }
 
-// Generate генерирует autoload.php
-func (g *Generator) Generate(lock *composer.ComposerLock, composerJSON *composer.ComposerJSON) error {
-	fmt.Println("🔧 Generating autoload files...")
+// SetOptimize включает оптимизацию: PSR-4/PSR-0 директории сканируются в classmap
+func (g *Generator) SetOptimize(optimize bool) {
+	g.optimize = optimize
+}
 
-	// Собираем все PSR-4 и PSR-0 mappings
-	psr4Map := make(map[string][]string)
-	psr0Map := make(map[string][]string)
-	classmapDirs := []string{}
-	files := []string{}
+// SetClassMapAuthoritative включает authoritative classmap: классы вне classmap
+// не ищутся в файловой системе. Включает и оптимизацию.
+func (g *Generator) SetClassMapAuthoritative(authoritative bool) {
+	g.authoritative = authoritative
+}
 
-	// Из composer.json проекта (относительно корня проекта)
-	projectRoot := filepath.Dir(g.vendorDir)
-	g.addAutoloadConfig(composerJSON.Autoload, psr4Map, psr0Map, &classmapDirs, &files, projectRoot)
-	g.addAutoloadConfig(composerJSON.AutoloadDev, psr4Map, psr0Map, &classmapDirs, &files, projectRoot)
+// SetApcu включает кеш найденных и отсутствующих классов в APCu.
+// Пустой prefix заменяется случайным, чтобы кеш сбрасывался при каждой генерации.
+func (g *Generator) SetApcu(enabled bool, prefix string) {
+	if !enabled {
+		g.apcuPrefix = ""
+		return
+	}
+	if prefix == "" {
+		random := make([]byte, 8)
+		rand.Read(random)
+		prefix = hex.EncodeToString(random)
+	}
+	g.apcuPrefix = prefix
+}
 
-	// Из всех установленных пакетов
-	for _, pkg := range lock.Packages {
-		packageDir := filepath.Join(g.vendorDir, pkg.Name)
-
-		// Читаем composer.json пакета напрямую
-		packageComposerPath := filepath.Join(packageDir, "composer.json")
-		if pkgComposer, err := composer.LoadComposerJSON(packageComposerPath); err == nil {
-			g.addAutoloadConfig(pkgComposer.Autoload, psr4Map, psr0Map, &classmapDirs, &files, packageDir)
-		} else {
-			// Fallback на данные из lock файла
-			g.addAutoloadConfig(pkg.Autoload, psr4Map, psr0Map, &classmapDirs, &files, packageDir)
+// absolute приводит путь к абсолютному виду (в случае ошибки возвращает как есть)
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// packageDir возвращает абсолютный путь к установленному пакету с учетом extra.installer-paths
+func (g *Generator) packageDir(pkg composer.LockedPackage) string {
+	if path := g.paths.Path(pkg); path != "" {
+		if filepath.IsAbs(path) {
+			return path
 		}
+		return filepath.Join(g.projectRoot, path)
 	}
+	return filepath.Join(g.vendorDir, pkg.Name)
+}
 
-	// Из дев пакетов
-	for _, pkg := range lock.PackagesDev {
-		packageDir := filepath.Join(g.vendorDir, pkg.Name)
+// Generate генерирует autoload.php. Без dev (--no-dev) dev пакеты и autoload-dev
+// корневого пакета не попадают в autoload и installed.json.
+func (g *Generator) Generate(lock *composer.ComposerLock, composerJSON *composer.ComposerJSON, dev bool) error {
+	fmt.Println("🔧 Generating autoload files...")
 
-		// Читаем composer.json пакета напрямую
-		packageComposerPath := filepath.Join(packageDir, "composer.json")
-		if pkgComposer, err := composer.LoadComposerJSON(packageComposerPath); err == nil {
-			g.addAutoloadConfig(pkgComposer.Autoload, psr4Map, psr0Map, &classmapDirs, &files, packageDir)
-		} else {
-			// Fallback на данные из lock файла
-			g.addAutoloadConfig(pkg.Autoload, psr4Map, psr0Map, &classmapDirs, &files, packageDir)
-		}
+	// Установленные пакеты: dev пакеты только в dev режиме
+	packages := append([]composer.LockedPackage{}, lock.Packages...)
+	if dev {
+		packages = append(packages, lock.PackagesDev...)
 	}
 
-	// Создаем autoload.php
-	if err := g.generateAutoloadPHP(psr4Map, psr0Map, classmapDirs, files); err != nil {
-		return err
+	g.paths = composer.NewInstallPaths(composerJSON, packages)
+
+	// Собираем все PSR-4, PSR-0, classmap и files mappings
+	layout := autoloadLayout{
+		psr4: make(map[string][]string),
+		psr0: make(map[string][]string),
 	}
 
+	// Из composer.json проекта (относительно корня проекта).
+	// autoload-dev учитывается только у корневого пакета, как в Composer.
+	rootName := composerJSON.Name
+	if rootName == "" {
+		rootName = "__root__"
+	}
+	g.addAutoloadConfig(&layout, composerJSON.Autoload, rootName, g.projectRoot)
+	if dev {
+		g.addAutoloadConfig(&layout, composerJSON.AutoloadDev, rootName, g.projectRoot)
+	}
+
+	// Из всех установленных пакетов (включая dev в dev режиме)
+	for _, pkg := range packages {
+		g.addPackageAutoload(&layout, pkg)
+	}
+
+	// Автоматически найденные bootstrap файлы, которых нет в autoload.files пакетов
+	layout.files = g.addBootstrapFiles(layout.files)
+
 	// Создаем ClassLoader.php
 	if err := g.generateClassLoader(); err != nil {
 		return err
@@ -89,7 +134,7 @@ func (g *Generator) Generate(lock *composer.ComposerLock, composerJSON *composer
 	}
 
 	// Создаем vendor/composer/installed.json для Composer 2 совместимости
-	if err := g.generateInstalledJson(lock); err != nil {
+	if err := g.generateInstalledJson(lock, dev); err != nil {
 		return err
 	}
 
@@ -103,8 +148,21 @@ func (g *Generator) Generate(lock *composer.ComposerLock, composerJSON *composer
 		return err
 	}
 
-	// Генерируем autoload_classmap.php
-	if err := g.generateClassmap(lock); err != nil {
+	// Собираем classmap (без путей из exclude-from-classmap)
+	exclude, err := excludeRegexp(layout.exclude)
+	if err != nil {
+		return err
+	}
+	layout.classMap = g.collectClassmap(layout.classmapDirs, exclude)
+	if g.optimize || g.authoritative {
+		added := g.optimizeClassmap(layout.psr4, layout.psr0, layout.classMap, exclude)
+		fmt.Printf("⚡ Optimized autoloader: %d classes from PSR-4/PSR-0 added to classmap\n", added)
+	}
+
+	// Создаем autoload.php, autoload_real.php, autoload_static.php и autoload_*.php
+	layout.authoritative = g.authoritative
+	layout.apcuPrefix = g.apcuPrefix
+	if err := g.writeAutoloadFiles(layout, autoloaderSuffix(lock, composerJSON, g.vendorDir)); err != nil {
 		return err
 	}
 
@@ -112,6 +170,20 @@ func (g *Generator) Generate(lock *composer.ComposerLock, composerJSON *composer
 	return nil
 }
 
+// autoloaderSuffix возвращает суффикс классов ComposerAutoloaderInit/ComposerStaticInit:
+// config.autoloader-suffix, content-hash lock файла или хеш пути к vendor.
+// Суффикс детерминирован, поэтому одинаковые зависимости дают одинаковые файлы.
+func autoloaderSuffix(lock *composer.ComposerLock, composerJSON *composer.ComposerJSON, vendorDir string) string {
+	if suffix := composer.LoadConfig(composerJSON).AutoloaderSuffix; suffix != "" {
+		return suffix
+	}
+	if lock.ContentHash != "" {
+		return lock.ContentHash
+	}
+	sum := md5.Sum([]byte(vendorDir))
+	return hex.EncodeToString(sum[:])
+}
+
 // patchFile заменяет текст в файле
 func (g *Generator) patchFile(filePath, oldText, newText string) error {
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -132,17 +204,33 @@ func (g *Generator) patchFile(filePath, oldText, newText string) error {
 	return os.WriteFile(filePath, []byte(newContent), 0644)
 }
 
-// addAutoloadConfig добавляет конфигурацию автозагрузки
-func (g *Generator) addAutoloadConfig(
-	config composer.AutoloadConfig,
-	psr4Map, psr0Map map[string][]string,
-	classmapDirs, files *[]string,
-	baseDir string,
-) {
+// addPackageAutoload добавляет autoload установленного пакета: из его composer.json,
+// а для пакетов без него (inline package) - из lock файла
+func (g *Generator) addPackageAutoload(layout *autoloadLayout, pkg composer.LockedPackage) {
+	packageDir := g.packageDir(pkg)
+
+	// Читаем composer.json пакета напрямую
+	packageComposerPath := filepath.Join(packageDir, "composer.json")
+	if pkgComposer, err := composer.LoadComposerJSON(packageComposerPath); err == nil {
+		g.addAutoloadConfig(layout, pkgComposer.Autoload, pkg.Name, packageDir)
+	} else {
+		// Fallback на данные из lock файла
+		g.addAutoloadConfig(layout, pkg.Autoload, pkg.Name, packageDir)
+	}
+}
+
+// addAutoloadConfig добавляет конфигурацию автозагрузки пакета name из директории baseDir
+func (g *Generator) addAutoloadConfig(layout *autoloadLayout, config composer.AutoloadConfig, name, baseDir string) {
 	// PSR-4
 	if config.PSR4 != nil {
-		for ns, pathInterface := range config.PSR4 {
+		for _, ns := range sortedKeys(config.PSR4) {
+			pathInterface := config.PSR4[ns]
 			namespace := ns
+			// ClassLoader::addPsr4 бросает исключение для такого prefix
+			if namespace != "" && !strings.HasSuffix(namespace, "\\") {
+				g.warn("PSR-4 prefix %q must end with a namespace separator, skipping", namespace)
+				continue
+			}
 			var paths []string
 
 			switch v := pathInterface.(type) {
@@ -163,14 +251,15 @@ func (g *Generator) addAutoloadConfig(
 				} else {
 					fullPath = path
 				}
-				psr4Map[namespace] = append(psr4Map[namespace], fullPath)
+				layout.psr4[namespace] = append(layout.psr4[namespace], fullPath)
 			}
 		}
 	}
 
 	// PSR-0
 	if config.PSR0 != nil {
-		for ns, pathInterface := range config.PSR0 {
+		for _, ns := range sortedKeys(config.PSR0) {
+			pathInterface := config.PSR0[ns]
 			namespace := ns
 			var paths []string
 
@@ -190,7 +279,7 @@ func (g *Generator) addAutoloadConfig(
 				if baseDir != "" {
 					fullPath = filepath.Join(baseDir, path)
 				}
-				psr0Map[namespace] = append(psr0Map[namespace], fullPath)
+				layout.psr0[namespace] = append(layout.psr0[namespace], fullPath)
 			}
 		}
 	}
@@ -201,7 +290,7 @@ func (g *Generator) addAutoloadConfig(
 		if baseDir != "" {
 			fullPath = filepath.Join(baseDir, dir)
 		}
-		*classmapDirs = append(*classmapDirs, fullPath)
+		layout.classmapDirs = append(layout.classmapDirs, fullPath)
 	}
 
 	// Files
@@ -210,294 +299,70 @@ func (g *Generator) addAutoloadConfig(
 		if baseDir != "" {
 			fullPath = filepath.Join(baseDir, file)
 		}
-		*files = append(*files, fullPath)
+		layout.files = append(layout.files, autoloadFile{id: fileIdentifier(name, file), path: fullPath})
 	}
-}
-
-// generateAutoloadPHP генерирует autoload.php
-func (g *Generator) generateAutoloadPHP(
-	psr4Map, psr0Map map[string][]string,
-	classmapDirs, files []string,
-) error {
-	autoloadPath := filepath.Join(g.vendorDir, "autoload.php")
-
-	content := `<?php
-
-// autoload.php @generated by go-composer
-
-require_once __DIR__ . '/ClassLoader.php';
 
-// Load Composer classes for Composer 2 compatibility
-if (file_exists(__DIR__ . '/composer/InstalledVersions.php')) {
-    require_once __DIR__ . '/composer/InstalledVersions.php';
-}
-if (file_exists(__DIR__ . '/composer/platform_check.php')) {
-    require_once __DIR__ . '/composer/platform_check.php';
+	// Exclude-from-classmap (пути относительно пакета, с * и **)
+	for _, pattern := range config.ExcludeFromClassmap {
+		layout.exclude = append(layout.exclude, excludePattern(baseDir, pattern))
+	}
 }
 
-$loader = new \Composer\Autoload\ClassLoader();
-
-// Load classmap
-if (file_exists(__DIR__ . '/composer/autoload_classmap.php')) {
-    $classMap = require __DIR__ . '/composer/autoload_classmap.php';
-    if ($classMap) {
-        $loader->addClassMap($classMap);
-    }
+// warn выводит предупреждение о конфигурации автозагрузки в stderr, как Composer:
+// stdout остается для сообщений о ходе генерации
+func (g *Generator) warn(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "⚠️  "+format+"\n", args...)
 }
 
-// PSR-4 autoloading
-`
-
-	// PSR-4
-	for namespace, paths := range psr4Map {
-		for _, path := range paths {
-			relPath := g.makeRelativePath(path)
-			content += fmt.Sprintf("$loader->addPsr4('%s', __DIR__ . '%s');\n",
-				strings.ReplaceAll(namespace, "\\", "\\\\"), relPath)
-		}
+// generateClassLoader генерирует vendor/composer/ClassLoader.php (полный ClassLoader Composer 2)
+func (g *Generator) generateClassLoader() error {
+	composerDir := filepath.Join(g.vendorDir, "composer")
+	if err := os.MkdirAll(composerDir, 0755); err != nil {
+		return err
 	}
 
-	content += "\n// PSR-0 autoloading\n"
-
-	// PSR-0
-	for namespace, paths := range psr0Map {
-		for _, path := range paths {
-			relPath := g.makeRelativePath(path)
-			content += fmt.Sprintf("$loader->add('%s', __DIR__ . '%s');\n",
-				strings.ReplaceAll(namespace, "\\", "\\\\"), relPath)
+	// Прежние версии создавали vendor/ClassLoader.php
+	legacyPath := filepath.Join(g.vendorDir, "ClassLoader.php")
+	if data, err := os.ReadFile(legacyPath); err == nil {
+		if string(data) == classLoaderPHP || strings.HasPrefix(string(data), "<?php\n\n// ClassLoader.php @generated by go-composer") {
+			os.Remove(legacyPath)
 		}
 	}
 
-	content += "\n$loader->register();\n\n"
-
-	// Автоматически находим и подключаем bootstrap файлы
-	bootstrapFiles := g.findBootstrapFiles()
-	if len(bootstrapFiles) > 0 {
-		content += "// Bootstrap files\n"
-		for _, file := range bootstrapFiles {
-			relPath := g.makeRelativePath(file)
-			content += fmt.Sprintf("if (file_exists(__DIR__ . '%s')) { require_once __DIR__ . '%s'; }\n", relPath, relPath)
-		}
-		content += "\n"
+	if err := os.WriteFile(filepath.Join(composerDir, "ClassLoader.php"), []byte(classLoaderPHP), 0644); err != nil {
+		return err
 	}
+	// Заголовок ClassLoader.php ссылается на LICENSE рядом с ним
+	return os.WriteFile(filepath.Join(composerDir, "LICENSE"), []byte(composerLicense), 0644)
+}
 
-	// Files из autoload
-	if len(files) > 0 {
-		content += "// Autoload files\n"
-		for _, file := range files {
-			relPath := g.makeRelativePath(file)
-			content += fmt.Sprintf("if (file_exists(__DIR__ . '%s')) { require_once __DIR__ . '%s'; }\n", relPath, relPath)
+// projectDirExpr возвращает PHP выражение для корня проекта из файла в директории dir:
+// dirname(__DIR__, N), если dir лежит внутри проекта, иначе абсолютный путь
+func (g *Generator) projectDirExpr(dir string) string {
+	rel, err := filepath.Rel(dir, g.projectRoot)
+	if err == nil && rel != "." {
+		levels := strings.Split(filepath.ToSlash(rel), "/")
+		inside := true
+		for _, level := range levels {
+			if level != ".." {
+				inside = false
+			}
+		}
+		if inside {
+			return fmt.Sprintf("dirname(__DIR__, %d)", len(levels))
 		}
 	}
-
-	content += "\nreturn $loader;\n"
-
-	return os.WriteFile(autoloadPath, []byte(content), 0644)
-}
-
-// generateClassLoader генерирует ClassLoader.php
-func (g *Generator) generateClassLoader() error {
-	classLoaderPath := filepath.Join(g.vendorDir, "ClassLoader.php")
-
-	content := `<?php
-
-// ClassLoader.php @generated by go-composer
-
-namespace Composer\Autoload;
-
-class ClassLoader
-{
-    private $prefixesPsr4 = [];
-    private $prefixesPsr0 = [];
-    private $classMap = [];
-
-    public function addPsr4($prefix, $baseDir)
-    {
-        // Нормализуем базовую директорию
-        $baseDir = rtrim($baseDir, '/\\') . '/';
-
-        if (!isset($this->prefixesPsr4[$prefix])) {
-            $this->prefixesPsr4[$prefix] = [];
-        }
-        $this->prefixesPsr4[$prefix][] = $baseDir;
-    }
-
-    public function add($prefix, $baseDir)
-    {
-        // Нормализуем базовую директорию
-        $baseDir = rtrim($baseDir, '/\\') . '/';
-
-        if (!isset($this->prefixesPsr0[$prefix])) {
-            $this->prefixesPsr0[$prefix] = [];
-        }
-        $this->prefixesPsr0[$prefix][] = $baseDir;
-    }
-
-    public function register()
-    {
-        spl_autoload_register([$this, 'loadClass']);
-    }
-
-    public function loadClass($class)
-    {
-        if ($file = $this->findFile($class)) {
-            require $file;
-            return true;
-        }
-        return false;
-    }
-
-    public function findFile($class)
-    {
-        // Проверяем в classmap
-        if (isset($this->classMap[$class])) {
-            return $this->classMap[$class];
-        }
-
-        // PSR-4
-        if ($file = $this->findFilePsr4($class)) {
-            if (file_exists($file)) {
-                return $file;
-            }
-        }
-
-        // PSR-0
-        if ($file = $this->findFilePsr0($class)) {
-            if (file_exists($file)) {
-                return $file;
-            }
-        }
-
-        return false;
-    }
-
-    private function findFilePsr4($class)
-    {
-        // Проверяем каждый зарегистрированный namespace
-        foreach ($this->prefixesPsr4 as $prefix => $dirs) {
-            // Проверяем, начинается ли класс с этого prefix
-            $len = strlen($prefix);
-            if (strncmp($prefix, $class, $len) === 0) {
-                // Получаем относительный путь класса (без prefix)
-                $relativeClass = substr($class, $len);
-
-                // Проверяем каждую директорию для этого prefix
-                foreach ($dirs as $dir) {
-                    // Формируем полный путь к файлу
-                    $file = $dir . str_replace('\\', '/', $relativeClass) . '.php';
-                    if (file_exists($file)) {
-                        return $file;
-                    }
-                }
-            }
-        }
-
-        return false;
-    }
-
-    private function findFilePsr0($class)
-    {
-        $pos = strrpos($class, '\\');
-
-        // Полное имя класса с namespace
-        $logicalPath = str_replace('\\', '/', $class) . '.php';
-
-        foreach ($this->prefixesPsr0 as $prefix => $dirs) {
-            if (strpos($class, $prefix) === 0) {
-                foreach ($dirs as $dir) {
-                    $file = $dir . $logicalPath;
-                    if (file_exists($file)) {
-                        return $file;
-                    }
-                }
-            }
-        }
-
-        return false;
-    }
-
-    // Методы для получения конфигурации (используются Symfony)
-    public function getPrefixes()
-    {
-        return $this->prefixesPsr0;
-    }
-
-    public function getPrefixesPsr4()
-    {
-        return $this->prefixesPsr4;
-    }
-
-    public function getClassMap()
-    {
-        return $this->classMap;
-    }
-
-    public function addClassMap(array $classMap)
-    {
-        if ($this->classMap) {
-            $this->classMap = array_merge($this->classMap, $classMap);
-        } else {
-            $this->classMap = $classMap;
-        }
-    }
-
-    public function getFallbackDirs()
-    {
-        return array();
-    }
-
-    public function getFallbackDirsPsr4()
-    {
-        return array();
-    }
-}
-`
-
-	return os.WriteFile(classLoaderPath, []byte(content), 0644)
+	return "'" + filepath.ToSlash(g.projectRoot) + "'"
 }
 
-// makeRelativePath делает путь относительным к vendor директории для PHP
-func (g *Generator) makeRelativePath(path string) string {
-	var absPath string
-
-	// Приводим путь к абсолютному виду
-	if filepath.IsAbs(path) {
-		absPath = path
-	} else {
-		// Убираем префикс vendor/ если есть
-		path = strings.TrimPrefix(path, "vendor/")
-		path = strings.TrimPrefix(path, "vendor\\")
-		// Делаем абсолютным относительно vendorDir
-		absPath = filepath.Join(g.vendorDir, path)
-	}
-
-	// Получаем относительный путь от vendor к целевому пути
-	rel, err := filepath.Rel(g.vendorDir, absPath)
-	if err != nil {
-		// В случае ошибки возвращаем как есть
-		return "/" + filepath.ToSlash(absPath)
-	}
-
-	// Если это текущая директория
-	if rel == "." {
-		return ""
-	}
-
-	// Преобразуем в Unix-style слэши для PHP
-	rel = filepath.ToSlash(rel)
-
-	// Добавляем / в начало для PHP пути
-	if !strings.HasPrefix(rel, "/") {
-		rel = "/" + rel
+// addBootstrapFiles добавляет к autoload.files известные bootstrap файлы полифилов,
+// если пакеты не объявили их сами
+func (g *Generator) addBootstrapFiles(files []autoloadFile) []autoloadFile {
+	known := make(map[string]bool, len(files))
+	for _, file := range files {
+		known[filepath.Clean(file.path)] = true
 	}
 
-	return rel
-}
-
-// findBootstrapFiles ищет bootstrap файлы в установленных пакетах
-func (g *Generator) findBootstrapFiles() []string {
-	var bootstrapFiles []string
-
 	// Список известных bootstrap файлов
 	bootstrapPatterns := []string{
 		"symfony/polyfill-*/bootstrap.php",
@@ -508,11 +373,15 @@ func (g *Generator) findBootstrapFiles() []string {
 	for _, pattern := range bootstrapPatterns {
 		matches, _ := filepath.Glob(filepath.Join(g.vendorDir, pattern))
 		for _, match := range matches {
-			bootstrapFiles = append(bootstrapFiles, match)
+			if known[filepath.Clean(match)] {
+				continue
+			}
+			rel, _ := filepath.Rel(g.vendorDir, match)
+			files = append(files, autoloadFile{id: fileIdentifier("bootstrap", filepath.ToSlash(rel)), path: match})
 		}
 	}
 
-	return bootstrapFiles
+	return files
 }
 
 // generateRuntimeAutoload генерирует autoload_runtime.php для Symfony Runtime
@@ -549,7 +418,7 @@ if (!is_object($app)) {
 
 $runtime = $_SERVER['APP_RUNTIME'] ?? $_ENV['APP_RUNTIME'] ?? 'Symfony\\Component\\Runtime\\SymfonyRuntime';
 $runtime = new $runtime(($_SERVER['APP_RUNTIME_OPTIONS'] ?? $_ENV['APP_RUNTIME_OPTIONS'] ?? []) + [
-        'project_dir' => dirname(__DIR__, 1),
+        'project_dir' => ` + g.projectDirExpr(g.vendorDir) + `,
     ]);
 
 [$app, $args] = $runtime
@@ -569,7 +438,7 @@ $runtime
 }
 
 // generateInstalledJson создает vendor/composer/installed.json для Composer 2
-func (g *Generator) generateInstalledJson(lock *composer.ComposerLock) error {
+func (g *Generator) generateInstalledJson(lock *composer.ComposerLock, dev bool) error {
 	composerDir := filepath.Join(g.vendorDir, "composer")
 	if err := os.MkdirAll(composerDir, 0755); err != nil {
 		return err
@@ -584,7 +453,7 @@ func (g *Generator) generateInstalledJson(lock *composer.ComposerLock) error {
 		Type    string `json:"type,omitempty"`
 	}
 
-	var packages []InstalledPackage
+	packages := []InstalledPackage{}
 	for _, pkg := range lock.Packages {
 		packages = append(packages, InstalledPackage{
 			Name:    pkg.Name,
@@ -593,10 +462,29 @@ func (g *Generator) generateInstalledJson(lock *composer.ComposerLock) error {
 		})
 	}
 
+	// dev пакеты установлены только в dev режиме (без --no-dev)
+	devPackageNames := []string{}
+	if dev {
+		for _, pkg := range lock.PackagesDev {
+			packages = append(packages, InstalledPackage{
+				Name:    pkg.Name,
+				Version: pkg.Version,
+				Type:    pkg.Type,
+			})
+			devPackageNames = append(devPackageNames, pkg.Name)
+		}
+	}
+
+	// Пакеты по имени, как в Composer
+	sort.Slice(packages, func(a, b int) bool {
+		return packages[a].Name < packages[b].Name
+	})
+	sort.Strings(devPackageNames)
+
 	installed := map[string]interface{}{
 		"packages":          packages,
-		"dev":               true,
-		"dev-package-names": []string{},
+		"dev":               dev,
+		"dev-package-names": devPackageNames,
 	}
 
 	data, err := json.MarshalIndent(installed, "", "    ")
@@ -666,7 +554,7 @@ class InstalledVersions
     {
         if (file_exists(__DIR__ . '/installed.json')) {
             return array(
-                'root' => array('install_path' => dirname(__DIR__, 2)),
+                'root' => array('install_path' => ` + g.projectDirExpr(composerDir) + `),
                 'versions' => json_decode(file_get_contents(__DIR__ . '/installed.json'), true),
             );
         }
@@ -715,66 +603,40 @@ if ($issues) {
 	return os.WriteFile(platformPath, []byte(content), 0644)
 }
 
-// generateClassmap генерирует autoload_classmap.php
-func (g *Generator) generateClassmap(lock *composer.ComposerLock) error {
-	composerDir := filepath.Join(g.vendorDir, "composer")
-	classmapPath := filepath.Join(composerDir, "autoload_classmap.php")
-
-	// Собираем classmap из всех пакетов
+// collectClassmap собирает classmap (класс -> файл) из classmap директорий и файлов
+func (g *Generator) collectClassmap(classmapDirs []string, exclude *regexp.Regexp) map[string]string {
 	classMap := make(map[string]string)
 
-	// Проходим по всем пакетам
-	for _, pkg := range lock.Packages {
-		packageDir := filepath.Join(g.vendorDir, pkg.Name)
-
-		// Читаем composer.json пакета
-		composerPath := filepath.Join(packageDir, "composer.json")
-		data, err := os.ReadFile(composerPath)
-		if err != nil {
-			continue // Пропускаем если файл не найден
-		}
-
-		var pkgComposer composer.ComposerJSON
-		if err := json.Unmarshal(data, &pkgComposer); err != nil {
-			continue
-		}
-
-		// Обрабатываем classmap из autoload
-		g.processClassmapDirs(pkgComposer.Autoload, packageDir, classMap)
-		g.processClassmapDirs(pkgComposer.AutoloadDev, packageDir, classMap)
-	}
-
-	// Генерируем PHP файл с classmap
-	content := "<?php\n\n// autoload_classmap.php @generated by go-composer\n\n"
-	content += "return array(\n"
-
-	for className, filePath := range classMap {
-		// Делаем путь относительным к vendor
-		relPath := g.makeRelativePath(filePath)
-		content += fmt.Sprintf("    '%s' => __DIR__ . '%s',\n",
-			strings.ReplaceAll(className, "\\", "\\\\"), relPath)
+	for _, dir := range classmapDirs {
+		// Сканируем директорию (или отдельный файл) на наличие PHP классов
+		g.scanClassmapDir(dir, classMap, exclude)
 	}
 
-	content += ");\n"
+	// InstalledVersions загружается через classmap, как в Composer
+	classMap["Composer\\InstalledVersions"] = filepath.Join(g.vendorDir, "composer", "InstalledVersions.php")
 
-	return os.WriteFile(classmapPath, []byte(content), 0644)
+	return classMap
 }
 
-// processClassmapDirs обрабатывает classmap директории из autoload конфигурации
-func (g *Generator) processClassmapDirs(config composer.AutoloadConfig, baseDir string, classMap map[string]string) {
-	if len(config.Classmap) == 0 {
+// scanClassmapDir рекурсивно сканирует директорию и находит PHP классы.
+// Пути, подходящие под exclude (exclude-from-classmap), пропускаются.
+func (g *Generator) scanClassmapDir(dir string, classMap map[string]string, exclude *regexp.Regexp) {
+	if excluded(exclude, dir) {
 		return
 	}
 
-	for _, dir := range config.Classmap {
-		fullPath := filepath.Join(baseDir, dir)
-		// Сканируем директорию на наличие PHP файлов
-		g.scanClassmapDir(fullPath, classMap)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return
+	}
+	if !info.IsDir() {
+		// В classmap можно указать и отдельный файл
+		for _, className := range g.extractClassNames(dir) {
+			g.addClass(classMap, className, dir)
+		}
+		return
 	}
-}
 
-// scanClassmapDir рекурсивно сканирует директорию и находит PHP классы
-func (g *Generator) scanClassmapDir(dir string, classMap map[string]string) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return
@@ -782,45 +644,50 @@ func (g *Generator) scanClassmapDir(dir string, classMap map[string]string) {
 
 	for _, entry := range entries {
 		fullPath := filepath.Join(dir, entry.Name())
+		if excluded(exclude, fullPath) {
+			continue
+		}
 
 		if entry.IsDir() {
 			// Рекурсивно сканируем поддиректории
-			g.scanClassmapDir(fullPath, classMap)
+			g.scanClassmapDir(fullPath, classMap, exclude)
 		} else if strings.HasSuffix(entry.Name(), ".php") {
-			// Пытаемся извлечь имя класса из файла
-			className := g.extractClassNameFromFile(fullPath)
-			if className != "" {
-				classMap[className] = fullPath
+			// Все классы, объявленные в файле
+			for _, className := range g.extractClassNames(fullPath) {
+				g.addClass(classMap, className, fullPath)
 			}
 		}
 	}
 }
 
-// extractClassNameFromFile извлекает fully qualified имя класса из PHP файла
-func (g *Generator) extractClassNameFromFile(filePath string) string {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return ""
+// addClass добавляет класс в classmap. Если класс уже найден в другом файле,
+// остается первый, а неоднозначность выводится предупреждением, как в Composer.
+func (g *Generator) addClass(classMap map[string]string, className, path string) bool {
+	if existing, ok := classMap[className]; ok {
+		if filepath.Clean(existing) != filepath.Clean(path) {
+			g.warn("Ambiguous class resolution, \"%s\" was found in both \"%s\" and \"%s\", the first will be used.",
+				className, g.displayPath(existing), g.displayPath(path))
+		}
+		return false
 	}
+	classMap[className] = path
+	return true
+}
 
-	content := string(data)
-
-	// Ищем namespace
-	var namespace string
-	namespaceRegex := regexp.MustCompile(`namespace\s+([a-zA-Z0-9_\\]+)\s*;`)
-	if matches := namespaceRegex.FindStringSubmatch(content); len(matches) > 1 {
-		namespace = matches[1]
+// extractClassNames возвращает fully qualified имена всех классов, интерфейсов,
+// трейтов и enum'ов, объявленных в PHP файле
+func (g *Generator) extractClassNames(filePath string) []string {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
 	}
 
-	// Ищем class, interface или trait
-	classRegex := regexp.MustCompile(`(?:class|interface|trait)\s+([a-zA-Z0-9_]+)`)
-	if matches := classRegex.FindStringSubmatch(content); len(matches) > 1 {
-		className := matches[1]
-		if namespace != "" {
-			return namespace + "\\" + className
-		}
-		return className
+	// Быстрая проверка без лексера: в файле нет ни одного ключевого слова объявления
+	lower := bytes.ToLower(data)
+	if !bytes.Contains(lower, []byte("class")) && !bytes.Contains(lower, []byte("interface")) &&
+		!bytes.Contains(lower, []byte("trait")) && !bytes.Contains(lower, []byte("enum")) {
+		return nil
 	}
 
-	return ""
+	return findClasses(data)
 }
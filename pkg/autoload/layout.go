@@ -0,0 +1,419 @@
+package autoload
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// autoloadFile - файл из autoload.files
+type autoloadFile struct {
+	// id - md5("<пакет>:<путь>"): по нему несколько autoloader'ов
+	// (проект и phar инструмента) не подключают один файл дважды
+	id   string
+	path string
+}
+
+// fileIdentifier вычисляет идентификатор файла так же, как Composer
+func fileIdentifier(name, path string) string {
+	sum := md5.Sum([]byte(name + ":" + path))
+	return hex.EncodeToString(sum[:])
+}
+
+// autoloadLayout - собранные mappings, из которых генерируются файлы vendor/composer
+type autoloadLayout struct {
+	psr4     map[string][]string
+	psr0     map[string][]string
+	classMap map[string]string
+	files    []autoloadFile
+
+	classmapDirs []string // директории и файлы autoload.classmap
+	exclude      []string // регулярные выражения exclude-from-classmap
+
+	authoritative bool   // setClassMapAuthoritative(true)
+	apcuPrefix    string // setApcuPrefix(...), пусто - без APCu
+}
+
+// writeAutoloadFiles генерирует стандартный набор файлов Composer:
+// vendor/autoload.php, composer/autoload_real.php, autoload_static.php,
+// autoload_psr4.php, autoload_namespaces.php, autoload_classmap.php и autoload_files.php
+func (g *Generator) writeAutoloadFiles(layout autoloadLayout, suffix string) error {
+	composerDir := filepath.Join(g.vendorDir, "composer")
+	if err := os.MkdirAll(composerDir, 0755); err != nil {
+		return err
+	}
+
+	generated := map[string]string{
+		"autoload_psr4.php":       g.dirsMapPHP("autoload_psr4.php", layout.psr4),
+		"autoload_namespaces.php": g.dirsMapPHP("autoload_namespaces.php", layout.psr0),
+		"autoload_classmap.php":   g.classmapPHP(layout.classMap),
+		"autoload_static.php":     g.autoloadStaticPHP(layout, suffix),
+		"autoload_real.php":       autoloadRealPHP(layout, suffix),
+	}
+	if len(layout.files) > 0 {
+		generated["autoload_files.php"] = g.filesPHP(layout.files)
+	} else if err := os.Remove(filepath.Join(composerDir, "autoload_files.php")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for name, content := range generated {
+		if err := os.WriteFile(filepath.Join(composerDir, name), []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	return os.WriteFile(filepath.Join(g.vendorDir, "autoload.php"), []byte(autoloadPHP(suffix)), 0644)
+}
+
+// autoloadPHP - vendor/autoload.php: проверка версии PHP и загрузка autoload_real.php
+func autoloadPHP(suffix string) string {
+	return `<?php
+
+// autoload.php @generated by go-composer
+
+if (PHP_VERSION_ID < 50600) {
+    if (!headers_sent()) {
+        header('HTTP/1.1 500 Internal Server Error');
+    }
+    $err = 'The autoloader requires PHP 5.6 or newer and you are running '.PHP_VERSION.', please upgrade PHP. Aborting.'.PHP_EOL;
+    if (!ini_get('display_errors')) {
+        if (PHP_SAPI === 'cli' || PHP_SAPI === 'phpdbg') {
+            fwrite(STDERR, $err);
+        } elseif (!headers_sent()) {
+            echo $err;
+        }
+    }
+    trigger_error(
+        $err,
+        E_USER_ERROR
+    );
+}
+
+require_once __DIR__ . '/composer/autoload_real.php';
+
+return ComposerAutoloaderInit` + suffix + `::getLoader();
+`
+}
+
+// autoloadRealPHP - класс ComposerAutoloaderInit<suffix>, создающий и регистрирующий ClassLoader
+func autoloadRealPHP(layout autoloadLayout, suffix string) string {
+	var b strings.Builder
+
+	b.WriteString(`<?php
+
+// autoload_real.php @generated by go-composer
+
+class ComposerAutoloaderInit` + suffix + `
+{
+    private static $loader;
+
+    public static function loadClassLoader($class)
+    {
+        if ('Composer\Autoload\ClassLoader' === $class) {
+            require __DIR__ . '/ClassLoader.php';
+        }
+    }
+
+    /**
+     * @return \Composer\Autoload\ClassLoader
+     */
+    public static function getLoader()
+    {
+        if (null !== self::$loader) {
+            return self::$loader;
+        }
+
+        require __DIR__ . '/platform_check.php';
+
+        spl_autoload_register(array('ComposerAutoloaderInit` + suffix + `', 'loadClassLoader'), true, true);
+        self::$loader = $loader = new \Composer\Autoload\ClassLoader(\dirname(__DIR__));
+        spl_autoload_unregister(array('ComposerAutoloaderInit` + suffix + `', 'loadClassLoader'));
+
+        require __DIR__ . '/autoload_static.php';
+        call_user_func(\Composer\Autoload\ComposerStaticInit` + suffix + `::getInitializer($loader));
+
+`)
+
+	if layout.authoritative {
+		b.WriteString("        $loader->setClassMapAuthoritative(true);\n")
+	}
+	if layout.apcuPrefix != "" {
+		b.WriteString("        $loader->setApcuPrefix(" + phpString(layout.apcuPrefix) + ");\n")
+	}
+	b.WriteString("        $loader->register(true);\n")
+
+	if len(layout.files) > 0 {
+		b.WriteString(`
+        $filesToLoad = \Composer\Autoload\ComposerStaticInit` + suffix + `::$files;
+        $requireFile = \Closure::bind(static function ($fileIdentifier, $file) {
+            if (empty($GLOBALS['__composer_autoload_files'][$fileIdentifier])) {
+                $GLOBALS['__composer_autoload_files'][$fileIdentifier] = true;
+
+                require $file;
+            }
+        }, null, null);
+        foreach ($filesToLoad as $fileIdentifier => $file) {
+            $requireFile($fileIdentifier, $file);
+        }
+`)
+	}
+
+	b.WriteString(`
+        return $loader;
+    }
+}
+`)
+	return b.String()
+}
+
+// autoloadStaticPHP - класс ComposerStaticInit<suffix>: mappings в виде статических
+// массивов, которые opcache держит в памяти, и инициализатор ClassLoader
+func (g *Generator) autoloadStaticPHP(layout autoloadLayout, suffix string) string {
+	var b strings.Builder
+	var initializer []string
+
+	b.WriteString(`<?php
+
+// autoload_static.php @generated by go-composer
+
+namespace Composer\Autoload;
+
+class ComposerStaticInit` + suffix + `
+{
+`)
+
+	if len(layout.files) > 0 {
+		b.WriteString("    public static $files = array (\n")
+		for _, file := range layout.files {
+			fmt.Fprintf(&b, "        %s => %s,\n", phpString(file.id), g.pathCode(file.path, true))
+		}
+		b.WriteString("    );\n\n")
+	}
+
+	prefixes, fallback := splitFallback(layout.psr4)
+	if len(prefixes) > 0 {
+		b.WriteString("    public static $prefixLengthsPsr4 = array (\n")
+		for _, group := range groupByFirstLetter(prefixes) {
+			fmt.Fprintf(&b, "        %s => \n        array (\n", phpString(group[0][:1]))
+			for _, prefix := range group {
+				fmt.Fprintf(&b, "            %s => %d,\n", phpString(prefix), len(prefix))
+			}
+			b.WriteString("        ),\n")
+		}
+		b.WriteString("    );\n\n")
+
+		b.WriteString("    public static $prefixDirsPsr4 = array (\n")
+		for _, prefix := range prefixes {
+			fmt.Fprintf(&b, "        %s => \n        ", phpString(prefix))
+			g.writeStaticDirs(&b, layout.psr4[prefix], "        ", ",")
+		}
+		b.WriteString("    );\n\n")
+		initializer = append(initializer, "prefixLengthsPsr4", "prefixDirsPsr4")
+	}
+	if len(fallback) > 0 {
+		b.WriteString("    public static $fallbackDirsPsr4 = ")
+		g.writeStaticDirs(&b, fallback, "    ", ";")
+		b.WriteString("\n")
+		initializer = append(initializer, "fallbackDirsPsr4")
+	}
+
+	prefixes, fallback = splitFallback(layout.psr0)
+	if len(prefixes) > 0 {
+		b.WriteString("    public static $prefixesPsr0 = array (\n")
+		for _, group := range groupByFirstLetter(prefixes) {
+			fmt.Fprintf(&b, "        %s => \n        array (\n", phpString(group[0][:1]))
+			for _, prefix := range group {
+				fmt.Fprintf(&b, "            %s => \n            ", phpString(prefix))
+				g.writeStaticDirs(&b, layout.psr0[prefix], "            ", ",")
+			}
+			b.WriteString("        ),\n")
+		}
+		b.WriteString("    );\n\n")
+		initializer = append(initializer, "prefixesPsr0")
+	}
+	if len(fallback) > 0 {
+		b.WriteString("    public static $fallbackDirsPsr0 = ")
+		g.writeStaticDirs(&b, fallback, "    ", ";")
+		b.WriteString("\n")
+		initializer = append(initializer, "fallbackDirsPsr0")
+	}
+
+	b.WriteString("    public static $classMap = array (\n")
+	for _, class := range sortedKeys(layout.classMap) {
+		fmt.Fprintf(&b, "        %s => %s,\n", phpString(class), g.pathCode(layout.classMap[class], true))
+	}
+	b.WriteString("    );\n\n")
+	initializer = append(initializer, "classMap")
+
+	b.WriteString(`    public static function getInitializer(ClassLoader $loader)
+    {
+        return \Closure::bind(function () use ($loader) {
+`)
+	for _, property := range initializer {
+		fmt.Fprintf(&b, "            $loader->%s = ComposerStaticInit%s::$%s;\n", property, suffix, property)
+	}
+	b.WriteString(`
+        }, null, ClassLoader::class);
+    }
+}
+`)
+
+	return b.String()
+}
+
+// writeStaticDirs выводит список директорий в формате autoload_static.php;
+// end завершает массив: "," для элемента массива или ";" для свойства
+func (g *Generator) writeStaticDirs(b *strings.Builder, dirs []string, indent, end string) {
+	b.WriteString("array (\n")
+	for n, dir := range dirs {
+		fmt.Fprintf(b, "%s    %d => %s,\n", indent, n, g.pathCode(dir, true))
+	}
+	b.WriteString(indent + ")" + end + "\n")
+}
+
+// dirsMapPHP генерирует autoload_psr4.php или autoload_namespaces.php
+func (g *Generator) dirsMapPHP(name string, dirs map[string][]string) string {
+	var b strings.Builder
+	b.WriteString(g.mapHeader(name))
+	for _, prefix := range sortedPrefixes(dirs) {
+		codes := make([]string, 0, len(dirs[prefix]))
+		for _, dir := range dirs[prefix] {
+			codes = append(codes, g.pathCode(dir, false))
+		}
+		fmt.Fprintf(&b, "    %s => array(%s),\n", phpString(prefix), strings.Join(codes, ", "))
+	}
+	b.WriteString(");\n")
+	return b.String()
+}
+
+// classmapPHP генерирует autoload_classmap.php
+func (g *Generator) classmapPHP(classMap map[string]string) string {
+	var b strings.Builder
+	b.WriteString(g.mapHeader("autoload_classmap.php"))
+	for _, class := range sortedKeys(classMap) {
+		fmt.Fprintf(&b, "    %s => %s,\n", phpString(class), g.pathCode(classMap[class], false))
+	}
+	b.WriteString(");\n")
+	return b.String()
+}
+
+// filesPHP генерирует autoload_files.php
+func (g *Generator) filesPHP(files []autoloadFile) string {
+	var b strings.Builder
+	b.WriteString(g.mapHeader("autoload_files.php"))
+	for _, file := range files {
+		fmt.Fprintf(&b, "    %s => %s,\n", phpString(file.id), g.pathCode(file.path, false))
+	}
+	b.WriteString(");\n")
+	return b.String()
+}
+
+// mapHeader - начало файлов autoload_*.php с переменными $vendorDir и $baseDir
+func (g *Generator) mapHeader(name string) string {
+	return "<?php\n\n// " + name + " @generated by go-composer\n\n" +
+		"$vendorDir = dirname(__DIR__);\n" +
+		"$baseDir = " + g.projectDirExpr(filepath.Join(g.vendorDir, "composer")) + ";\n\n" +
+		"return array(\n"
+}
+
+// pathCode возвращает PHP выражение для пути: относительно vendor-dir или корня проекта
+// ($vendorDir/$baseDir, а в autoload_static.php - от __DIR__), иначе абсолютный путь
+func (g *Generator) pathCode(path string, static bool) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.projectRoot, path)
+	}
+
+	if rel, ok := within(g.vendorDir, path); ok {
+		if static {
+			return "__DIR__ . '/..'" + pathSuffix(rel)
+		}
+		return "$vendorDir" + pathSuffix(rel)
+	}
+	if rel, ok := within(g.projectRoot, path); ok {
+		if static {
+			base, err := filepath.Rel(filepath.Join(g.vendorDir, "composer"), g.projectRoot)
+			if err == nil {
+				return "__DIR__ . " + phpString("/"+filepath.ToSlash(base)) + pathSuffix(rel)
+			}
+		} else {
+			return "$baseDir" + pathSuffix(rel)
+		}
+	}
+	return phpString(filepath.ToSlash(path))
+}
+
+// within возвращает путь относительно dir, если path находится внутри dir
+func within(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// pathSuffix - продолжение выражения пути (" . '/rel'") или пустая строка для самой директории
+func pathSuffix(rel string) string {
+	if rel == "." {
+		return ""
+	}
+	return " . " + phpString("/"+rel)
+}
+
+// phpString экранирует строку для PHP литерала в одинарных кавычках
+func phpString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// splitFallback отделяет директории пустого prefix (fallback) от остальных prefix'ов
+func splitFallback(dirs map[string][]string) (prefixes []string, fallback []string) {
+	for _, prefix := range sortedPrefixes(dirs) {
+		if prefix == "" {
+			fallback = dirs[prefix]
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, fallback
+}
+
+// groupByFirstLetter группирует prefix'ы по первому символу, как ClassLoader
+func groupByFirstLetter(prefixes []string) [][]string {
+	var groups [][]string
+	index := map[string]int{}
+	for _, prefix := range prefixes {
+		first := prefix[:1]
+		n, ok := index[first]
+		if !ok {
+			n = len(groups)
+			index[first] = n
+			groups = append(groups, nil)
+		}
+		groups[n] = append(groups[n], prefix)
+	}
+	return groups
+}
+
+// sortedKeys возвращает ключи map по алфавиту, чтобы одинаковые входные данные
+// давали побайтово одинаковые файлы
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedPrefixes возвращает prefix'ы PSR-4/PSR-0: сначала длинные (более
+// конкретные namespace), при равной длине - по алфавиту
+func sortedPrefixes(m map[string][]string) []string {
+	prefixes := sortedKeys(m)
+	sort.SliceStable(prefixes, func(a, b int) bool {
+		return len(prefixes[a]) > len(prefixes[b])
+	})
+	return prefixes
+}
@@ -0,0 +1,92 @@
+package autoload
+
+import (
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// optimizeClassmap добавляет в classmap все классы из PSR-4 и PSR-0 директорий (-o).
+// Классы, путь которых не соответствует стандарту, пропускаются с предупреждением,
+// как в Composer. Явные записи classmap приоритетнее найденных сканированием,
+// пути из exclude-from-classmap не сканируются.
+func (g *Generator) optimizeClassmap(psr4Map, psr0Map map[string][]string, classMap map[string]string, exclude *regexp.Regexp) int {
+	added := 0
+
+	scan := func(standard, prefix string, dirs []string) {
+		for _, dir := range dirs {
+			filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if excluded(exclude, path) {
+					if entry.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if entry.IsDir() || !strings.HasSuffix(path, ".php") {
+					return nil
+				}
+
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return nil
+				}
+				rel = filepath.ToSlash(rel)
+
+				for _, className := range g.extractClassNames(path) {
+					if !compliesWith(standard, prefix, rel, className) {
+						g.warn("Class %s located in %s does not comply with %s autoloading standard (rule: %s => %s). Skipping.",
+							className, g.displayPath(path), standard, prefix, g.displayPath(dir))
+						continue
+					}
+					if g.addClass(classMap, className, path) {
+						added++
+					}
+				}
+				return nil
+			})
+		}
+	}
+
+	// Порядок prefix'ов фиксирован: при неоднозначности всегда выигрывает один и тот же файл
+	for _, prefix := range sortedPrefixes(psr0Map) {
+		scan("psr-0", prefix, psr0Map[prefix])
+	}
+	for _, prefix := range sortedPrefixes(psr4Map) {
+		scan("psr-4", prefix, psr4Map[prefix])
+	}
+
+	return added
+}
+
+// compliesWith проверяет, что класс className из файла rel (путь относительно
+// директории правила) загружался бы по правилу prefix стандарта psr-4 или psr-0
+func compliesWith(standard, prefix, rel, className string) bool {
+	if !strings.HasPrefix(className, prefix) {
+		return false
+	}
+
+	if standard == "psr-4" {
+		expected := prefix + strings.ReplaceAll(strings.TrimSuffix(rel, ".php"), "/", "\\")
+		return className == expected
+	}
+
+	// PSR-0: разделители namespace и "_" в имени класса соответствуют директориям
+	namespace, name := "", className
+	if i := strings.LastIndex(className, "\\"); i >= 0 {
+		namespace, name = className[:i+1], className[i+1:]
+	}
+	expected := strings.ReplaceAll(namespace, "\\", "/") + strings.ReplaceAll(name, "_", "/") + ".php"
+	return rel == expected
+}
+
+// displayPath возвращает путь относительно корня проекта для сообщений
+func (g *Generator) displayPath(path string) string {
+	if rel, ok := within(g.projectRoot, path); ok {
+		return rel
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,286 @@
+package autoload
+
+import (
+	"bytes"
+	"strings"
+)
+
+// phpLexer разбивает PHP код на токены, достаточные для поиска объявлений:
+// имена, переменные и знаки. Комментарии, строки, heredoc/nowdoc и HTML вне
+// <?php ... ?> пропускаются, поэтому слово "class" в них не считается объявлением.
+type phpLexer struct {
+	src    []byte
+	pos    int
+	tokens []string
+}
+
+// findClasses возвращает полные имена всех классов, интерфейсов, трейтов и enum'ов,
+// объявленных в PHP коде (анонимные классы пропускаются)
+func findClasses(src []byte) []string {
+	l := &phpLexer{src: src}
+	l.run()
+	return declarations(l.tokens)
+}
+
+// run токенизирует весь файл, начиная с HTML режима
+func (l *phpLexer) run() {
+	for l.pos < len(l.src) {
+		if !l.skipInlineHTML() {
+			return
+		}
+		if !l.lexPHP() {
+			return
+		}
+	}
+}
+
+// skipInlineHTML пропускает текст до открывающего тега; false - тегов больше нет
+func (l *phpLexer) skipInlineHTML() bool {
+	i := bytes.Index(l.src[l.pos:], []byte("<?"))
+	if i < 0 {
+		l.pos = len(l.src)
+		return false
+	}
+	l.pos += i + 2
+	if len(l.src)-l.pos >= 3 && strings.EqualFold(string(l.src[l.pos:l.pos+3]), "php") {
+		l.pos += 3
+	} else if l.pos < len(l.src) && l.src[l.pos] == '=' {
+		l.pos++
+	}
+	return true
+}
+
+// lexPHP токенизирует код до закрывающего тега ?>; false - достигнут __halt_compiler
+func (l *phpLexer) lexPHP() bool {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case l.has("?->"):
+			l.emit("?->", 3)
+		case l.has("?>"):
+			// Закрывающий тег завершает выражение
+			l.emit(";", 2)
+			return true
+		case l.has("#["):
+			// Атрибут PHP 8, его содержимое токенизируется как обычный код
+			l.emit("#[", 2)
+		case c == '#' || l.has("//"):
+			l.skipLineComment()
+		case l.has("/*"):
+			if end := bytes.Index(l.src[l.pos+2:], []byte("*/")); end >= 0 {
+				l.pos += end + 4
+			} else {
+				l.pos = len(l.src)
+			}
+		case c == '\'':
+			l.skipQuoted('\'')
+		case c == '"' || c == '`':
+			l.skipQuoted(c)
+		case l.has("<<<"):
+			l.skipHeredoc()
+		case c == '$' && l.pos+1 < len(l.src) && isNameByte(l.src[l.pos+1]) && l.src[l.pos+1] != '\\':
+			start := l.pos
+			l.pos++
+			for l.pos < len(l.src) && isNameByte(l.src[l.pos]) && l.src[l.pos] != '\\' {
+				l.pos++
+			}
+			l.tokens = append(l.tokens, string(l.src[start:l.pos]))
+		case isNameByte(c):
+			start := l.pos
+			for l.pos < len(l.src) && isNameByte(l.src[l.pos]) {
+				l.pos++
+			}
+			name := string(l.src[start:l.pos])
+			if strings.EqualFold(name, "__halt_compiler") {
+				return false
+			}
+			l.tokens = append(l.tokens, name)
+		case l.has("::"):
+			l.emit("::", 2)
+		case l.has("->"):
+			l.emit("->", 2)
+		default:
+			l.emit(string(c), 1)
+		}
+	}
+	return true
+}
+
+// has проверяет, что код в текущей позиции начинается с s
+func (l *phpLexer) has(s string) bool {
+	return bytes.HasPrefix(l.src[l.pos:], []byte(s))
+}
+
+// emit добавляет токен и сдвигает позицию на n байт
+func (l *phpLexer) emit(token string, n int) {
+	l.tokens = append(l.tokens, token)
+	l.pos += n
+}
+
+// skipLineComment пропускает // и # комментарии: они заканчиваются переводом строки или ?>
+func (l *phpLexer) skipLineComment() {
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' && !l.has("?>") {
+		l.pos++
+	}
+}
+
+// skipQuoted пропускает строку в кавычках quote, включая интерполяцию {$...}
+// с вложенными строками в двойных кавычках и обратных апострофах
+func (l *phpLexer) skipQuoted(quote byte) {
+	l.pos++
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '\\':
+			l.pos += 2
+		case c == quote:
+			l.pos++
+			return
+		case quote != '\'' && l.has("{$"):
+			l.skipInterpolation()
+		default:
+			l.pos++
+		}
+	}
+}
+
+// skipInterpolation пропускает выражение {$...} внутри строки до парной скобки
+func (l *phpLexer) skipInterpolation() {
+	depth := 0
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case '{':
+			depth++
+			l.pos++
+		case '}':
+			depth--
+			l.pos++
+			if depth == 0 {
+				return
+			}
+		case '\'', '"', '`':
+			l.skipQuoted(c)
+		default:
+			l.pos++
+		}
+	}
+}
+
+// skipHeredoc пропускает heredoc/nowdoc (<<<ID, <<<"ID", <<<'ID') до закрывающего
+// идентификатора, который с PHP 7.3 может иметь отступ
+func (l *phpLexer) skipHeredoc() {
+	l.pos += 3
+	for l.pos < len(l.src) && (l.src[l.pos] == ' ' || l.src[l.pos] == '\t') {
+		l.pos++
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '\'' || l.src[l.pos] == '"') {
+		l.pos++
+	}
+	start := l.pos
+	for l.pos < len(l.src) && isNameByte(l.src[l.pos]) && l.src[l.pos] != '\\' {
+		l.pos++
+	}
+	label := l.src[start:l.pos]
+	if len(label) == 0 {
+		// "<<<" без метки - не heredoc
+		return
+	}
+
+	for {
+		newline := bytes.IndexByte(l.src[l.pos:], '\n')
+		if newline < 0 {
+			l.pos = len(l.src)
+			return
+		}
+		l.pos += newline + 1
+
+		line := l.pos
+		for line < len(l.src) && (l.src[line] == ' ' || l.src[line] == '\t') {
+			line++
+		}
+		end := line + len(label)
+		if bytes.HasPrefix(l.src[line:], label) && (end >= len(l.src) || !isNameByte(l.src[end]) || l.src[end] == '\\') {
+			l.pos = end
+			return
+		}
+	}
+}
+
+// isNameByte - символ имени PHP (буквы, цифры, _, \ и байты UTF-8)
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '\\' || c >= 0x80
+}
+
+// isIdentifier проверяет, что токен - простое имя (не переменная, не число, без namespace)
+func isIdentifier(token string) bool {
+	if token == "" || strings.ContainsRune(token, '\\') {
+		return false
+	}
+	c := token[0]
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// declarations находит объявления в потоке токенов с учетом namespace
+// (namespace X; и блочного namespace X { ... })
+func declarations(tokens []string) []string {
+	var classes []string
+	namespace := ""
+
+	token := func(i int) string {
+		if i < 0 || i >= len(tokens) {
+			return ""
+		}
+		return strings.ToLower(tokens[i])
+	}
+
+	for i := range tokens {
+		prev := token(i - 1)
+		if prev == "::" || prev == "->" || prev == "?->" || prev == "function" || prev == "const" {
+			// Foo::class, $x->class, function class(), const CLASS
+			continue
+		}
+
+		switch token(i) {
+		case "namespace":
+			switch {
+			case token(i+1) == "{":
+				namespace = ""
+			case i+1 < len(tokens) && isNamespaceName(tokens[i+1]) && (token(i+2) == ";" || token(i+2) == "{"):
+				namespace = strings.Trim(tokens[i+1], "\\") + "\\"
+			}
+
+		case "class", "interface", "trait", "enum":
+			if i+1 >= len(tokens) || !isIdentifier(tokens[i+1]) {
+				// new class(...) {...}, new class extends X {...} и прочие анонимные классы
+				continue
+			}
+			if next := token(i + 1); next == "extends" || next == "implements" {
+				continue
+			}
+			if token(i) == "enum" {
+				// enum - мягкое ключевое слово: enum Suit {, enum Suit: string {, enum Suit implements X {
+				if after := token(i + 2); after != "{" && after != ":" && after != "implements" {
+					continue
+				}
+			}
+			classes = append(classes, namespace+tokens[i+1])
+		}
+	}
+
+	return classes
+}
+
+// isNamespaceName проверяет имя namespace (Foo\Bar, без ведущего namespace\)
+func isNamespaceName(token string) bool {
+	if token == "" || strings.HasPrefix(strings.ToLower(token), "namespace\\") {
+		return false
+	}
+	for _, part := range strings.Split(strings.Trim(token, "\\"), "\\") {
+		if !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package autoload
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindClasses(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "class in namespace",
+			src:  "<?php\nnamespace App\\Model;\n\nfinal class User extends Base implements \\JsonSerializable {}\n",
+			want: []string{"App\\Model\\User"},
+		},
+		{
+			name: "global namespace",
+			src:  "<?php\nabstract class Foo {}\ninterface Bar {}\ntrait Baz {}\n",
+			want: []string{"Foo", "Bar", "Baz"},
+		},
+		{
+			name: "several namespaces",
+			src:  "<?php\nnamespace A;\nclass X {}\nnamespace B;\nclass Y {}\n",
+			want: []string{"A\\X", "B\\Y"},
+		},
+		{
+			name: "bracketed namespaces",
+			src:  "<?php\nnamespace A\\B {\n    class X {}\n}\nnamespace {\n    class Y {}\n}\n",
+			want: []string{"A\\B\\X", "Y"},
+		},
+		{
+			name: "namespace relative name is not a declaration",
+			src:  "<?php\nnamespace App;\n$x = namespace\\helper();\nclass Real {}\n",
+			want: []string{"App\\Real"},
+		},
+		{
+			name: "comments",
+			src:  "<?php\n// class LineComment {}\n# class HashComment {}\n/* class BlockComment {} */\n/** @see class DocComment */\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "strings",
+			src:  "<?php\n$a = 'class Single {}';\n$b = \"class Double {$obj->class} {}\";\n$c = `class Backtick`;\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "escaped quotes",
+			src:  "<?php\n$a = 'it\\'s class Fake {}';\n$b = \"say \\\"class Fake2\\\"\";\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "heredoc",
+			src:  "<?php\n$a = <<<EOT\nclass InHeredoc {}\nEOT;\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "quoted heredoc with indented closing label",
+			src:  "<?php\nfunction f() {\n    return <<<\"SQL\"\n        class InHeredoc {}\n        SQL;\n}\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "nowdoc",
+			src:  "<?php\n$a = <<<'EOT'\nclass InNowdoc {}\nEOT;\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "heredoc label prefix inside body",
+			src:  "<?php\n$a = <<<EOT\nEOTX class Fake {}\nEOT;\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "class constant and property access",
+			src:  "<?php\nnamespace App;\n$a = Foo::class;\n$b = $obj->class;\n$c = $obj?->class;\nclass Real {}\n",
+			want: []string{"App\\Real"},
+		},
+		{
+			name: "class as method and constant name",
+			src:  "<?php\nclass Real {\n    const CLASS_NAME = 'x';\n    public function class() {}\n}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "anonymous classes",
+			src:  "<?php\n$a = new class {};\n$b = new class(1, 2) extends Base {};\n$c = new class implements I {};\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "attributes",
+			src:  "<?php\nnamespace App;\n#[Attribute(Attribute::TARGET_CLASS)]\nfinal class Route {}\n#[Route('/'), Deprecated]\nclass Controller {}\n",
+			want: []string{"App\\Route", "App\\Controller"},
+		},
+		{
+			name: "enums",
+			src:  "<?php\nnamespace App;\nenum Suit {\n    case Hearts;\n}\nenum Status: string implements HasLabel {\n    case Active = 'active';\n}\nenum Plain implements HasLabel {}\n",
+			want: []string{"App\\Suit", "App\\Status", "App\\Plain"},
+		},
+		{
+			name: "enum as identifier",
+			src:  "<?php\n$enum = 1;\nfunction enum() {}\nenum(1);\nclass Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "inline html and closing tag",
+			src:  "<html>class Html {}</html>\n<?php class A {} ?>\nclass NotPhp {}\n<?= 'x' ?>\n<?php class B {}\n",
+			want: []string{"A", "B"},
+		},
+		{
+			name: "line comment ends at closing tag",
+			src:  "<?php // comment ?>class NotPhp {}\n<?php class Real {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "halt compiler",
+			src:  "<?php\nclass Real {}\n__halt_compiler();\nclass AfterHalt {}\n",
+			want: []string{"Real"},
+		},
+		{
+			name: "case insensitive keywords",
+			src:  "<?php\nNAMESPACE App;\nCLASS Upper {}\nInterface Mixed {}\n",
+			want: []string{"App\\Upper", "App\\Mixed"},
+		},
+		{
+			name: "no php tag",
+			src:  "class Plain {}\n",
+			want: nil,
+		},
+		{
+			name: "unterminated comment",
+			src:  "<?php\nclass Real {}\n/* class Fake {}",
+			want: []string{"Real"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findClasses([]byte(tt.src))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("findClasses() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
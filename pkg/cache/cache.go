@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Cache управляет локальным кешем архивов пакетов и метаданных репозиториев.
+// Структура каталога повторяет Composer:
+//
+//	<root>/files/<vendor>/<name>/<reference>.<ext>  - архивы дистрибутивов
+//	<root>/repo/<repository>/<name>.json             - метаданные пакетов
+//	<root>/vcs/<url>                                  - зеркала git репозиториев
+//
+// Директории files, repo и vcs можно вынести отдельно (cache-*-dir).
+type Cache struct {
+	root     string
+	filesDir string
+	repoDir  string
+	vcsDir   string
+}
+
+// unsafeChars - символы, которые заменяются при построении путей в кеше
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9._~-]`)
+
+// New создает кеш в указанной директории
+func New(root string) *Cache {
+	return NewDirs(root, "", "", "")
+}
+
+// NewDirs создает кеш с отдельными директориями архивов, метаданных и VCS зеркал;
+// пустые значения означают поддиректорию root
+func NewDirs(root, filesDir, repoDir, vcsDir string) *Cache {
+	if filesDir == "" {
+		filesDir = filepath.Join(root, "files")
+	}
+	if repoDir == "" {
+		repoDir = filepath.Join(root, "repo")
+	}
+	if vcsDir == "" {
+		vcsDir = filepath.Join(root, "vcs")
+	}
+	return &Cache{root: root, filesDir: filesDir, repoDir: repoDir, vcsDir: vcsDir}
+}
+
+// DefaultDir возвращает директорию кеша по умолчанию.
+// Учитывает COMPOSER_CACHE_DIR, иначе использует пользовательский кеш ОС.
+func DefaultDir() string {
+	if dir := os.Getenv("COMPOSER_CACHE_DIR"); dir != "" {
+		return dir
+	}
+
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "go-composer")
+	}
+
+	return filepath.Join(os.TempDir(), "go-composer-cache")
+}
+
+// Root возвращает корневую директорию кеша
+func (c *Cache) Root() string {
+	return c.root
+}
+
+// DistPath возвращает путь к архиву пакета в кеше.
+// Архив идентифицируется по reference, а если его нет - по хешу URL.
+func (c *Cache) DistPath(name, reference, url, distType string) string {
+	key := reference
+	if key == "" {
+		hash := sha1.Sum([]byte(url))
+		key = hex.EncodeToString(hash[:])
+	}
+
+	ext := distType
+	if ext == "" {
+		ext = "zip"
+	}
+
+	return filepath.Join(c.filesDir, sanitizeName(name), sanitize(key)+"."+sanitize(ext))
+}
+
+// HasFile проверяет, что файл кеша существует и не пустой
+func (c *Cache) HasFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Size() > 0
+}
+
+// Store атомарно записывает данные в файл кеша
+func (c *Cache) Store(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// RepoPath возвращает путь к файлу метаданных пакета для репозитория
+func (c *Cache) RepoPath(repoURL, name string) string {
+	return filepath.Join(c.repoDir, sanitize(repoURL), sanitize(strings.ReplaceAll(name, "/", "~"))+".json")
+}
+
+// VCSPath возвращает путь к bare-зеркалу VCS репозитория
+func (c *Cache) VCSPath(url string) string {
+	return filepath.Join(c.vcsDir, sanitize(url))
+}
+
+// RepoEntry - закешированный ответ репозитория вместе с заголовками для ревалидации
+type RepoEntry struct {
+	Data         []byte `json:"-"`
+	LastModified string `json:"last-modified,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+}
+
+// ReadRepo читает закешированные метаданные пакета.
+// Заголовки ревалидации хранятся рядом в файле <name>.json.meta.
+func (c *Cache) ReadRepo(repoURL, name string) (*RepoEntry, error) {
+	path := c.RepoPath(repoURL, name)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("metadata for %s is not cached", name)
+		}
+		return nil, err
+	}
+
+	entry := &RepoEntry{}
+	if meta, err := os.ReadFile(path + ".meta"); err == nil {
+		// Поврежденный meta файл означает лишь отсутствие ревалидации
+		json.Unmarshal(meta, entry)
+	}
+	entry.Data = data
+
+	return entry, nil
+}
+
+// WriteRepo сохраняет метаданные пакета и заголовки ревалидации в кеш
+func (c *Cache) WriteRepo(repoURL, name string, entry *RepoEntry) error {
+	path := c.RepoPath(repoURL, name)
+
+	if err := c.Store(path, entry.Data); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return c.Store(path+".meta", meta)
+}
+
+// sanitize заменяет небезопасные для файловой системы символы
+func sanitize(s string) string {
+	return unsafeChars.ReplaceAllString(s, "-")
+}
+
+// sanitizeName сохраняет разделение vendor/name на поддиректории
+func sanitizeName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = sanitize(part)
+	}
+	return filepath.Join(parts...)
+}
@@ -1,7 +1,9 @@
 package composer
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 )
 
@@ -49,7 +51,7 @@ type ComposerJSON struct {
 	RequireDev  map[string]string            `json:"require-dev,omitempty"`
 	Autoload    AutoloadConfig               `json:"autoload,omitempty"`
 	AutoloadDev AutoloadConfig               `json:"autoload-dev,omitempty"`
-	Repositories []Repository                `json:"repositories,omitempty"`
+	Repositories Repositories                `json:"repositories,omitempty"`
 	Config      map[string]interface{}       `json:"config,omitempty"`
 	Scripts     Scripts                      `json:"scripts,omitempty"`
 	Extra       map[string]interface{}       `json:"extra,omitempty"`
@@ -74,8 +76,139 @@ type AutoloadConfig struct {
 
 // Repository представляет репозиторий пакетов
 type Repository struct {
-	Type string `json:"type"`
-	URL  string `json:"url,omitempty"`
+	Type    string                 `json:"type"`
+	URL     string                 `json:"url,omitempty"`
+	Options map[string]interface{} `json:"options,omitempty"`
+	// Package - описание пакета (объект или массив версий) для type "package"
+	Package json.RawMessage `json:"package,omitempty"`
+
+	// Canonical (по умолчанию true) запрещает искать найденный здесь пакет в следующих репозиториях
+	Canonical *bool `json:"canonical,omitempty"`
+	// Only - шаблоны имен пакетов, которые может отдавать репозиторий
+	Only []string `json:"only,omitempty"`
+	// Exclude - шаблоны имен пакетов, которые репозиторий не отдает
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Name - ключ репозитория, если repositories задан объектом
+	Name string `json:"-"`
+	// Disabled - репозиторий отключен записью вида {"packagist.org": false}
+	Disabled bool `json:"-"`
+}
+
+// PackagistName - имя репозитория packagist.org, которое можно отключить.
+// Composer также принимает короткое имя "packagist".
+const PackagistName = "packagist.org"
+
+// isPackagistName проверяет, что имя репозитория обозначает packagist.org
+func isPackagistName(name string) bool {
+	return name == PackagistName || name == "packagist"
+}
+
+// IsCanonical возвращает значение canonical (по умолчанию true)
+func (r Repository) IsCanonical() bool {
+	return r.Canonical == nil || *r.Canonical
+}
+
+// Repositories - список репозиториев в порядке приоритета.
+// В composer.json задается массивом или объектом "имя -> репозиторий".
+type Repositories []Repository
+
+// UnmarshalJSON разбирает массив или объект репозиториев, включая {"packagist.org": false}
+func (r *Repositories) UnmarshalJSON(data []byte) error {
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err == nil {
+		repos := make(Repositories, 0, len(list))
+		for _, item := range list {
+			repo, err := parseRepository("", item)
+			if err != nil {
+				return err
+			}
+			repos = append(repos, repo...)
+		}
+		*r = repos
+		return nil
+	}
+
+	// Объектная форма: порядок ключей важен, поэтому читаем токены по очереди
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	var repos Repositories
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		name, _ := token.(string)
+
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return err
+		}
+
+		repo, err := parseRepository(name, item)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, repo...)
+	}
+
+	*r = repos
+	return nil
+}
+
+// parseRepository разбирает один элемент repositories
+func parseRepository(name string, data json.RawMessage) ([]Repository, error) {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		if enabled {
+			return nil, nil
+		}
+		return []Repository{{Name: name, Disabled: true}}, nil
+	}
+
+	// Элемент массива вида {"packagist.org": false}
+	var toggle map[string]bool
+	if name == "" && json.Unmarshal(data, &toggle) == nil && len(toggle) == 1 {
+		for key, enabled := range toggle {
+			if enabled {
+				return nil, nil
+			}
+			return []Repository{{Name: key, Disabled: true}}, nil
+		}
+	}
+
+	repo := Repository{Name: name}
+	if err := json.Unmarshal(data, &repo); err != nil {
+		return nil, fmt.Errorf("invalid repository %s: %w", name, err)
+	}
+	return []Repository{repo}, nil
+}
+
+// MarshalJSON сохраняет репозитории массивом
+func (r Repositories) MarshalJSON() ([]byte, error) {
+	items := make([]interface{}, 0, len(r))
+	for _, repo := range r {
+		if repo.Disabled {
+			items = append(items, map[string]bool{repo.Name: false})
+			continue
+		}
+		items = append(items, repo)
+	}
+	return json.Marshal(items)
+}
+
+// PackagistDisabled проверяет, отключен ли packagist.org ({"packagist.org": false}
+// или {"packagist": false})
+func (r Repositories) PackagistDisabled() bool {
+	for _, repo := range r {
+		if repo.Disabled && isPackagistName(repo.Name) {
+			return true
+		}
+	}
+	return false
 }
 
 // LoadComposerJSON загружает и парсит composer.json
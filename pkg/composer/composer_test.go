@@ -0,0 +1,34 @@
+package composer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRepositoriesPackagistDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want bool
+	}{
+		{"no repositories", `[]`, false},
+		{"array with packagist.org", `[{"packagist.org": false}]`, true},
+		{"array with packagist", `[{"packagist": false}]`, true},
+		{"object with packagist.org", `{"packagist.org": false}`, true},
+		{"object with packagist", `{"private": {"type": "composer", "url": "https://repo.example.com"}, "packagist": false}`, true},
+		{"packagist enabled", `[{"packagist": true}]`, false},
+		{"other repository disabled", `{"private": false}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var repos Repositories
+			if err := json.Unmarshal([]byte(tt.json), &repos); err != nil {
+				t.Fatal(err)
+			}
+			if got := repos.PackagistDisabled(); got != tt.want {
+				t.Errorf("PackagistDisabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
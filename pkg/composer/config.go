@@ -0,0 +1,132 @@
+package composer
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Значения config по умолчанию (как в Composer)
+const (
+	DefaultVendorDir = "vendor"
+	DefaultBinDir    = "{$vendor-dir}/bin"
+)
+
+// configRef - ссылка на другой параметр config ("{$vendor-dir}/bin")
+var configRef = regexp.MustCompile(`\{\$([a-z0-9-]+)\}`)
+
+// Config - параметры секции config composer.json с учетом переменных
+// окружения (они приоритетнее) и значений по умолчанию. Пути относительны
+// корня проекта, если не заданы абсолютными.
+type Config struct {
+	VendorDir string // vendor-dir, COMPOSER_VENDOR_DIR
+	BinDir    string // bin-dir, COMPOSER_BIN_DIR
+	BinCompat string // bin-compat: auto, proxy, full или symlink
+
+	// CacheDir - cache-dir, COMPOSER_CACHE_DIR; пусто - кеш ОС по умолчанию
+	CacheDir      string
+	CacheFilesDir string // cache-files-dir, по умолчанию <cache-dir>/files
+	CacheRepoDir  string // cache-repo-dir, по умолчанию <cache-dir>/repo
+	CacheVcsDir   string // cache-vcs-dir, по умолчанию <cache-dir>/vcs
+
+	PreferredInstall PreferredInstall
+	DiscardChanges   string // DiscardChangesPrompt, DiscardChangesTrue или DiscardChangesStash
+
+	AutoloaderSuffix string // autoloader-suffix: суффикс класса ComposerAutoloaderInit
+
+	OptimizeAutoloader    bool // optimize-autoloader
+	ClassmapAuthoritative bool // classmap-authoritative
+	ApcuAutoloader        bool // apcu-autoloader
+
+	GitHubDomains []string // github-domains: домены GitHub Enterprise для github-oauth
+}
+
+// LoadConfig собирает Config из composer.json (composerJSON может быть nil) и окружения
+func LoadConfig(composerJSON *ComposerJSON) Config {
+	raw := map[string]interface{}{}
+	if composerJSON != nil && composerJSON.Config != nil {
+		raw = composerJSON.Config
+	}
+
+	value := func(key, env, fallback string) string {
+		if env != "" {
+			if v := os.Getenv(env); v != "" {
+				return v
+			}
+		}
+		if v, ok := raw[key].(string); ok && v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	values := map[string]string{
+		"vendor-dir":      value("vendor-dir", "COMPOSER_VENDOR_DIR", DefaultVendorDir),
+		"bin-dir":         value("bin-dir", "COMPOSER_BIN_DIR", DefaultBinDir),
+		"bin-compat":      value("bin-compat", "", ""),
+		"cache-dir":       value("cache-dir", "COMPOSER_CACHE_DIR", ""),
+		"cache-files-dir": value("cache-files-dir", "", ""),
+		"cache-repo-dir":  value("cache-repo-dir", "", ""),
+		"cache-vcs-dir":   value("cache-vcs-dir", "", ""),
+	}
+	path := func(key string) string {
+		return expandPath(values[key], values)
+	}
+
+	config := Config{
+		VendorDir:     path("vendor-dir"),
+		BinDir:        path("bin-dir"),
+		BinCompat:     values["bin-compat"],
+		CacheDir:      path("cache-dir"),
+		CacheFilesDir: path("cache-files-dir"),
+		CacheRepoDir:  path("cache-repo-dir"),
+		CacheVcsDir:   path("cache-vcs-dir"),
+
+		PreferredInstall: ParsePreferredInstall(raw["preferred-install"]),
+		DiscardChanges:   ParseDiscardChanges(raw["discard-changes"]),
+		AutoloaderSuffix: value("autoloader-suffix", "", ""),
+
+		OptimizeAutoloader:    boolValue(raw["optimize-autoloader"]),
+		ClassmapAuthoritative: boolValue(raw["classmap-authoritative"]),
+		ApcuAutoloader:        boolValue(raw["apcu-autoloader"]),
+
+		GitHubDomains: stringValues(raw["github-domains"]),
+	}
+	if env, ok := os.LookupEnv("COMPOSER_DISCARD_CHANGES"); ok {
+		config.DiscardChanges = ParseDiscardChanges(env)
+	}
+
+	return config
+}
+
+// boolValue читает булев параметр config (true или строка "true"/"1")
+func boolValue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "1"
+	}
+	return false
+}
+
+// expandPath подставляет ссылки {$key} на другие параметры и раскрывает ~ в домашнюю директорию
+func expandPath(value string, values map[string]string) string {
+	for depth := 0; depth < 5 && strings.Contains(value, "{$"); depth++ {
+		value = configRef.ReplaceAllStringFunc(value, func(ref string) string {
+			return values[configRef.FindStringSubmatch(ref)[1]]
+		})
+	}
+
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			value = filepath.Join(home, strings.TrimPrefix(value, "~"))
+		}
+	}
+
+	if value == "" {
+		return ""
+	}
+	return filepath.Clean(filepath.FromSlash(value))
+}
@@ -0,0 +1,29 @@
+package composer
+
+import "strings"
+
+// Поведение при локальных изменениях в vendor (config.discard-changes)
+const (
+	DiscardChangesPrompt = "false" // спросить, без терминала - прервать установку
+	DiscardChangesTrue   = "true"  // перезаписать изменения
+	DiscardChangesStash  = "stash" // сохранить изменения и вернуть их после установки
+)
+
+// ParseDiscardChanges разбирает config.discard-changes (true, false или "stash")
+// и одноименную переменную окружения COMPOSER_DISCARD_CHANGES
+func ParseDiscardChanges(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return DiscardChangesTrue
+		}
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "1":
+			return DiscardChangesTrue
+		case "stash":
+			return DiscardChangesStash
+		}
+	}
+	return DiscardChangesPrompt
+}
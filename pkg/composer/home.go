@@ -0,0 +1,35 @@
+package composer
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// HomeDir возвращает COMPOSER_HOME: значение переменной окружения,
+// иначе ~/.config/composer (XDG), если он существует, иначе ~/.composer
+func HomeDir() string {
+	if dir := os.Getenv("COMPOSER_HOME"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	if xdgDir := filepath.Join(configHome, "composer"); dirExists(xdgDir) {
+		return xdgDir
+	}
+
+	return filepath.Join(home, ".composer")
+}
+
+// dirExists проверяет существование директории
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,208 @@
+package composer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// installerPlugins - плагины, с которыми Composer устанавливает пакеты вне vendor.
+// go-composer не запускает PHP плагины, поэтому их поведение реализовано здесь.
+var installerPlugins = []string{"composer/installers", "oomphinc/composer-installers-extender"}
+
+// frameworkPaths - пути по умолчанию composer/installers для типов "<фреймворк>-<тип>"
+var frameworkPaths = map[string]map[string]string{
+	"drupal": {
+		"core":             "core/",
+		"module":           "modules/{$name}/",
+		"theme":            "themes/{$name}/",
+		"library":          "libraries/{$name}/",
+		"profile":          "profiles/{$name}/",
+		"database-driver":  "drivers/lib/Drupal/Driver/Database/{$name}/",
+		"drush":            "drush/{$name}/",
+		"custom-theme":     "themes/custom/{$name}/",
+		"custom-module":    "modules/custom/{$name}/",
+		"custom-profile":   "profiles/custom/{$name}/",
+		"multisite":        "sites/{$name}/",
+		"console":          "console/{$name}/",
+		"console-language": "console/language/{$name}/",
+		"config":           "config/sync/",
+		"recipe":           "recipes/{$name}",
+	},
+	"wordpress": {
+		"plugin":   "wp-content/plugins/{$name}/",
+		"theme":    "wp-content/themes/{$name}/",
+		"muplugin": "wp-content/mu-plugins/{$name}/",
+		"dropin":   "wp-content/{$name}/",
+	},
+	"cakephp": {
+		"plugin": "Plugin/{$name}/",
+	},
+	"laravel": {
+		"library": "libraries/{$name}/",
+	},
+}
+
+// InstallPaths вычисляет директории установки пакетов по extra.installer-paths
+// корневого composer.json (формат composer/installers): ключ - путь с подстановками
+// {$name}, {$vendor}, {$type}, значение - имена пакетов и селекторы "type:" и "vendor:".
+type InstallPaths struct {
+	enabled  bool
+	types    map[string]bool // extra.installer-types (composer-installers-extender)
+	byName   map[string]string
+	byType   map[string]string
+	byVendor map[string]string
+}
+
+// NewInstallPaths читает extra.installer-paths. Пути применяются, только если среди
+// зависимостей есть composer/installers (или composer-installers-extender), как в Composer.
+func NewInstallPaths(root *ComposerJSON, packages []LockedPackage) *InstallPaths {
+	p := &InstallPaths{
+		types:    map[string]bool{},
+		byName:   map[string]string{},
+		byType:   map[string]string{},
+		byVendor: map[string]string{},
+	}
+	if root == nil {
+		return p
+	}
+
+	for _, plugin := range installerPlugins {
+		if _, ok := root.Require[plugin]; ok {
+			p.enabled = true
+		}
+	}
+	for _, pkg := range packages {
+		for _, plugin := range installerPlugins {
+			if pkg.Name == plugin {
+				p.enabled = true
+			}
+		}
+	}
+
+	for _, t := range stringValues(root.Extra["installer-types"]) {
+		p.types[t] = true
+	}
+
+	paths, _ := root.Extra["installer-paths"].(map[string]interface{})
+
+	// Порядок ключей JSON объекта не сохраняется: при совпадении селекторов
+	// выбирается первый путь по алфавиту, чтобы результат был детерминированным
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	sort.Strings(keys)
+
+	for _, path := range keys {
+		for _, selector := range stringValues(paths[path]) {
+			target := p.byName
+			switch {
+			case strings.HasPrefix(selector, "type:"):
+				target, selector = p.byType, strings.TrimPrefix(selector, "type:")
+			case strings.HasPrefix(selector, "vendor:"):
+				target, selector = p.byVendor, strings.TrimPrefix(selector, "vendor:")
+			}
+			if _, exists := target[selector]; !exists {
+				target[selector] = path
+			}
+		}
+	}
+
+	return p
+}
+
+// Path возвращает директорию установки пакета (относительно корня проекта)
+// или пустую строку, если пакет устанавливается в vendor.
+// Имя пакета важнее селектора type:, а type: важнее vendor:.
+func (p *InstallPaths) Path(pkg LockedPackage) string {
+	if p == nil || !p.enabled || pkg.Type == "" {
+		return ""
+	}
+
+	framework, subtype := pkg.Type, pkg.Type
+	if i := strings.Index(pkg.Type, "-"); i > 0 {
+		framework, subtype = pkg.Type[:i], pkg.Type[i+1:]
+	}
+
+	defaults, known := frameworkPaths[framework]
+	if known {
+		if _, ok := defaults[subtype]; !ok {
+			known = false
+		}
+	}
+	if !known && !p.types[pkg.Type] {
+		return ""
+	}
+	if !known {
+		subtype = pkg.Type
+	}
+
+	vendor, name := pkg.Name, pkg.Name
+	if i := strings.Index(pkg.Name, "/"); i >= 0 {
+		vendor, name = pkg.Name[:i], pkg.Name[i+1:]
+	}
+	if installerName, ok := pkg.Extra["installer-name"].(string); ok && safeInstallerName(installerName) {
+		name = installerName
+	} else if framework == "cakephp" {
+		name = camelize(name)
+	}
+
+	template, ok := p.byName[pkg.Name]
+	if !ok {
+		template, ok = p.byType[pkg.Type]
+	}
+	if !ok {
+		template, ok = p.byVendor[vendor]
+	}
+	if !ok && known {
+		template, ok = defaults[subtype], true
+	}
+	if !ok {
+		return ""
+	}
+
+	path := strings.NewReplacer("{$name}", name, "{$vendor}", vendor, "{$type}", subtype).Replace(template)
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+// Dir возвращает директорию пакета: путь из installer-paths или vendorDir/<name>
+func (p *InstallPaths) Dir(vendorDir string, pkg LockedPackage) string {
+	if path := p.Path(pkg); path != "" {
+		return path
+	}
+	return filepath.Join(vendorDir, pkg.Name)
+}
+
+// safeInstallerName проверяет extra.installer-name зависимости: имя с разделителями
+// пути или ".." установило бы пакет за пределы директории из installer-paths,
+// поэтому такое имя игнорируется
+func safeInstallerName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\`) && !strings.Contains(name, "..")
+}
+
+// camelize преобразует debug_kit/debug-kit в DebugKit (имена плагинов CakePHP)
+func camelize(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// stringValues приводит строку или массив строк из JSON к []string
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
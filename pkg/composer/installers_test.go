@@ -0,0 +1,75 @@
+package composer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallPathsPath(t *testing.T) {
+	root := &ComposerJSON{
+		Require: map[string]string{"composer/installers": "^2.0"},
+		Extra: map[string]interface{}{
+			"installer-paths": map[string]interface{}{
+				"web/modules/contrib/{$name}": []interface{}{"type:drupal-module"},
+				"custom/{$vendor}/{$name}":    []interface{}{"acme/special"},
+			},
+		},
+	}
+	paths := NewInstallPaths(root, nil)
+
+	tests := []struct {
+		name string
+		pkg  LockedPackage
+		want string
+	}{
+		{"plain library", LockedPackage{Name: "acme/lib", Type: "library"}, ""},
+		{"installer-paths by type", LockedPackage{Name: "drupal/token", Type: "drupal-module"}, "web/modules/contrib/token"},
+		{"installer-paths by name", LockedPackage{Name: "acme/special", Type: "drupal-module"}, "custom/acme/special"},
+		{"framework default", LockedPackage{Name: "drupal/core", Type: "drupal-core"}, "core"},
+		{"drupal multisite", LockedPackage{Name: "acme/site", Type: "drupal-multisite"}, "sites/site"},
+		{"cakephp camelized name", LockedPackage{Name: "cakephp/debug_kit", Type: "cakephp-plugin"}, "Plugin/DebugKit"},
+		{
+			"installer-name",
+			LockedPackage{Name: "drupal/token", Type: "drupal-module", Extra: map[string]interface{}{"installer-name": "token_custom"}},
+			"web/modules/contrib/token_custom",
+		},
+		{
+			"installer-name with parent directory",
+			LockedPackage{Name: "drupal/token", Type: "drupal-module", Extra: map[string]interface{}{"installer-name": "../../../../etc"}},
+			"web/modules/contrib/token",
+		},
+		{
+			"installer-name with absolute path",
+			LockedPackage{Name: "drupal/token", Type: "drupal-module", Extra: map[string]interface{}{"installer-name": "/tmp/evil"}},
+			"web/modules/contrib/token",
+		},
+		{
+			"installer-name with backslash",
+			LockedPackage{Name: "drupal/token", Type: "drupal-module", Extra: map[string]interface{}{"installer-name": `..\evil`}},
+			"web/modules/contrib/token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paths.Path(tt.pkg); got != filepath.FromSlash(tt.want) {
+				t.Errorf("Path() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallPathsRequireInstallersPlugin(t *testing.T) {
+	root := &ComposerJSON{Extra: map[string]interface{}{
+		"installer-paths": map[string]interface{}{"web/modules/{$name}": []interface{}{"type:drupal-module"}},
+	}}
+	pkg := LockedPackage{Name: "drupal/token", Type: "drupal-module"}
+
+	if got := NewInstallPaths(root, nil).Path(pkg); got != "" {
+		t.Errorf("Path() without composer/installers = %q, want vendor", got)
+	}
+	installers := []LockedPackage{{Name: "composer/installers"}}
+	if got := NewInstallPaths(root, installers).Path(pkg); got != filepath.FromSlash("web/modules/token") {
+		t.Errorf("Path() with locked composer/installers = %q", got)
+	}
+}
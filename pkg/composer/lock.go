@@ -79,6 +79,8 @@ type LockedPackage struct {
 	RequireDev       map[string]string      `json:"require-dev,omitempty"`
 	Type             string                 `json:"type,omitempty"`
 	Autoload         AutoloadConfig         `json:"autoload,omitempty"`
+	Bin              []string               `json:"bin,omitempty"`
+	Extra            map[string]interface{} `json:"extra,omitempty"`
 	NotificationURL  string                 `json:"notification-url,omitempty"`
 	License          []string               `json:"license,omitempty"`
 	Authors          []Author               `json:"authors,omitempty"`
@@ -88,6 +90,7 @@ type LockedPackage struct {
 	Time             string                 `json:"time,omitempty"`
 	Support          map[string]string      `json:"support,omitempty"`
 	Funding          []map[string]string    `json:"funding,omitempty"`
+	TransportOptions map[string]interface{} `json:"transport-options,omitempty"`
 }
 
 // Source представляет источник пакета (git, svn и т.д.)
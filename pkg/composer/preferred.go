@@ -0,0 +1,75 @@
+package composer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Способы установки пакета (config.preferred-install)
+const (
+	InstallDist   = "dist"
+	InstallSource = "source"
+	InstallAuto   = "auto" // source для dev-версий, dist для остальных
+)
+
+// PreferredInstall - способ установки по умолчанию и правила для отдельных пакетов
+type PreferredInstall struct {
+	Default string
+	Rules   []PreferredInstallRule
+}
+
+// PreferredInstallRule - способ установки для пакетов, подходящих под шаблон ("acme/*")
+type PreferredInstallRule struct {
+	Pattern string
+	Mode    string
+}
+
+// ParsePreferredInstall разбирает config.preferred-install: строку
+// ("dist", "source", "auto") или объект "шаблон -> способ"
+func ParsePreferredInstall(value interface{}) PreferredInstall {
+	preferred := PreferredInstall{Default: InstallDist}
+
+	switch v := value.(type) {
+	case string:
+		if isInstallMode(v) {
+			preferred.Default = v
+		}
+	case map[string]interface{}:
+		for pattern, mode := range v {
+			if mode, ok := mode.(string); ok && isInstallMode(mode) {
+				preferred.Rules = append(preferred.Rules, PreferredInstallRule{Pattern: pattern, Mode: mode})
+			}
+		}
+		// Порядок ключей JSON объекта не сохраняется, поэтому более
+		// конкретные шаблоны проверяются первыми ("acme/*" раньше "*")
+		sort.Slice(preferred.Rules, func(a, b int) bool {
+			pa, pb := preferred.Rules[a].Pattern, preferred.Rules[b].Pattern
+			if len(pa) != len(pb) {
+				return len(pa) > len(pb)
+			}
+			return pa < pb
+		})
+	}
+
+	return preferred
+}
+
+// For возвращает способ установки пакета: первое подходящее правило или значение по умолчанию
+func (p PreferredInstall) For(name string) string {
+	for _, rule := range p.Rules {
+		expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(rule.Pattern), `\*`, ".*") + "$"
+		if matched, _ := regexp.MatchString(expr, name); matched {
+			return rule.Mode
+		}
+	}
+	if p.Default == "" {
+		return InstallDist
+	}
+	return p.Default
+}
+
+// isInstallMode проверяет допустимое значение preferred-install
+func isInstallMode(mode string) bool {
+	return mode == InstallDist || mode == InstallSource || mode == InstallAuto
+}
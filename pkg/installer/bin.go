@@ -0,0 +1,377 @@
+package installer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/xman12/go-composer/pkg/composer"
+)
+
+// Способы установки bin файлов (config.bin-compat)
+const (
+	BinCompatAuto    = "auto"    // proxy-скрипты (и .bat на Windows)
+	BinCompatProxy   = "proxy"   // то же, что auto
+	BinCompatFull    = "full"    // proxy-скрипты и .bat на любой ОС
+	BinCompatSymlink = "symlink" // symlink на файл пакета
+)
+
+// installBinaries создает в bin-dir proxy-скрипты (или symlink'и) для bin файлов пакета.
+// Файл с тем же именем, принадлежащий другому пакету или проекту, не перезаписывается.
+func (i *Installer) installBinaries(pkg composer.LockedPackage) error {
+	if len(pkg.Bin) == 0 {
+		return nil
+	}
+
+	i.binMu.Lock()
+	defer i.binMu.Unlock()
+
+	if err := os.MkdirAll(i.binDir, 0755); err != nil {
+		return err
+	}
+
+	for _, bin := range pkg.Bin {
+		source := filepath.Join(i.paths.Dir(i.vendorDir, pkg), filepath.FromSlash(bin))
+		info, err := os.Stat(source)
+		if err != nil || info.IsDir() {
+			fmt.Printf("  ⚠️  Skipped installation of bin %s for package %s: file not found in package\n", bin, pkg.Name)
+			continue
+		}
+
+		link := filepath.Join(i.binDir, filepath.Base(bin))
+		if !i.ownsBinary(link, source) {
+			fmt.Printf("  ⚠️  Skipped installation of bin %s for package %s: name conflicts with an existing file\n", bin, pkg.Name)
+			continue
+		}
+
+		// Исходный файл тоже должен быть исполняемым (архивы часто теряют права)
+		if err := os.Chmod(source, info.Mode().Perm()|0111); err != nil {
+			return err
+		}
+
+		if err := i.linkBinary(source, link); err != nil {
+			return fmt.Errorf("failed to install bin %s: %w", bin, err)
+		}
+	}
+
+	return nil
+}
+
+// removeBinaries удаляет proxy-скрипты и symlink'и bin файлов пакета из packageDir
+func (i *Installer) removeBinaries(packageDir string, bins []string) {
+	i.binMu.Lock()
+	defer i.binMu.Unlock()
+
+	for _, bin := range bins {
+		source := filepath.Join(packageDir, filepath.FromSlash(bin))
+		link := filepath.Join(i.binDir, filepath.Base(bin))
+
+		for _, file := range []string{link, link + ".bat"} {
+			if _, err := os.Lstat(file); err == nil && i.ownsBinary(file, source) {
+				os.Remove(file)
+			}
+		}
+	}
+}
+
+// linkBinary создает файл в bin-dir по config.bin-compat
+func (i *Installer) linkBinary(source, link string) error {
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if i.binCompat == BinCompatSymlink {
+		target, err := relativeBinPath(i.binDir, source)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(filepath.FromSlash(target), link)
+	}
+
+	proxy, err := i.binProxy(source)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(link, []byte(proxy), 0755); err != nil {
+		return err
+	}
+	// WriteFile не меняет права существующего файла, а umask мог их урезать
+	if err := os.Chmod(link, 0755); err != nil {
+		return err
+	}
+
+	if i.binCompat == BinCompatFull || runtime.GOOS == "windows" {
+		bat, err := i.binBatch(source)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(link+".bat", []byte(bat), 0644)
+	}
+
+	return nil
+}
+
+// ownsBinary проверяет, что файл в bin-dir отсутствует или создан для source:
+// symlink на source или proxy-скрипт, ссылающийся на source
+func (i *Installer) ownsBinary(link, source string) bool {
+	info, err := os.Lstat(link)
+	if err != nil {
+		return true
+	}
+
+	target, err := relativeBinPath(i.binDir, source)
+	if err != nil {
+		return false
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		current, err := os.Readlink(link)
+		return err == nil && filepath.ToSlash(current) == target
+	}
+
+	data, err := os.ReadFile(link)
+	if err != nil {
+		return false
+	}
+	content := string(data)
+	return strings.Contains(content, "generated by Composer") && strings.Contains(content, target)
+}
+
+// binProxy возвращает proxy-скрипт: PHP для PHP файлов, shell для остальных
+func (i *Installer) binProxy(source string) (string, error) {
+	target, err := relativeBinPath(i.binDir, source)
+	if err != nil {
+		return "", err
+	}
+
+	if !isPHPScript(source) {
+		return fmt.Sprintf(shellProxyTemplate, target, path.Dir(target), path.Base(target)), nil
+	}
+
+	autoload, err := relativeBinPath(i.binDir, filepath.Join(i.vendorDir, "autoload.php"))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(phpProxyTemplate, target, autoload, target, target), nil
+}
+
+// binBatch возвращает .bat proxy для Windows
+func (i *Installer) binBatch(source string) (string, error) {
+	target, err := relativeBinPath(i.binDir, source)
+	if err != nil {
+		return "", err
+	}
+
+	caller := ""
+	if isPHPScript(source) {
+		caller = "php "
+	}
+	return fmt.Sprintf(batchProxyTemplate, strings.ReplaceAll(target, "/", "\\"), caller), nil
+}
+
+// relativeBinPath возвращает путь к target относительно bin-dir (через "/")
+func relativeBinPath(binDir, target string) (string, error) {
+	absBin, err := filepath.Abs(binDir)
+	if err != nil {
+		return "", err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(absBin, absTarget)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// isPHPScript проверяет по первой строке, что файл - PHP скрипт:
+// shebang с php или открывающий тег <?php
+func isPHPScript(name string) bool {
+	file, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	line, _ := bufio.NewReader(file).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#!") {
+		return strings.Contains(line, "php")
+	}
+	return strings.HasPrefix(line, "<?php")
+}
+
+// Шаблоны proxy скопированы из Composer 2 (MIT, Nils Adermann, Jordi Boggiano),
+// уведомление о лицензии - в ATTRIBUTION.md.
+
+// phpProxyTemplate - PHP proxy в формате Composer 2: на PHP < 8 shebang
+// исходного файла вырезается stream wrapper'ом, чтобы он не попал в вывод
+const phpProxyTemplate = `#!/usr/bin/env php
+<?php
+
+/**
+ * Proxy PHP file generated by Composer
+ *
+ * This file includes the referenced bin path (%s)
+ * using a stream wrapper to prevent the shebang from being output on PHP<8
+ *
+ * @generated
+ */
+
+namespace Composer;
+
+$GLOBALS['_composer_bin_dir'] = __DIR__;
+$GLOBALS['_composer_autoload_path'] = __DIR__ . '/%s';
+
+if (PHP_VERSION_ID < 80000) {
+    if (!class_exists('Composer\BinProxyWrapper')) {
+        /**
+         * @internal
+         */
+        final class BinProxyWrapper
+        {
+            private $handle;
+            private $position;
+            private $realpath;
+
+            public function stream_open($path, $mode, $options, &$opened_path)
+            {
+                // get rid of phpvfscomposer:// prefix for __FILE__ & __DIR__ resolution
+                $opened_path = substr($path, 17);
+                $this->realpath = realpath($opened_path) ?: $opened_path;
+                $opened_path = $this->realpath;
+                $this->handle = fopen($this->realpath, $mode);
+                $this->position = 0;
+
+                return (bool) $this->handle;
+            }
+
+            public function stream_read($count)
+            {
+                $data = fread($this->handle, $count);
+
+                if ($this->position === 0) {
+                    $data = preg_replace('{^#!.*\r?\n}', '', $data);
+                }
+
+                $this->position += strlen($data);
+
+                return $data;
+            }
+
+            public function stream_cast($castAs)
+            {
+                return $this->handle;
+            }
+
+            public function stream_close()
+            {
+                fclose($this->handle);
+            }
+
+            public function stream_lock($operation)
+            {
+                return $operation ? flock($this->handle, $operation) : true;
+            }
+
+            public function stream_seek($offset, $whence)
+            {
+                if (0 === fseek($this->handle, $offset, $whence)) {
+                    $this->position = ftell($this->handle);
+                    return true;
+                }
+
+                return false;
+            }
+
+            public function stream_tell()
+            {
+                return $this->position;
+            }
+
+            public function stream_eof()
+            {
+                return feof($this->handle);
+            }
+
+            public function stream_stat()
+            {
+                return array();
+            }
+
+            public function stream_set_option($option, $arg1, $arg2)
+            {
+                return true;
+            }
+
+            public function url_stat($path, $flags)
+            {
+                $path = substr($path, 17);
+                if (file_exists($path)) {
+                    return stat($path);
+                }
+
+                return false;
+            }
+        }
+    }
+
+    if (
+        (function_exists('stream_get_wrappers') && in_array('phpvfscomposer', stream_get_wrappers(), true))
+        || (function_exists('stream_wrapper_register') && stream_wrapper_register('phpvfscomposer', 'Composer\BinProxyWrapper'))
+    ) {
+        return include("phpvfscomposer://" . __DIR__ . '/%s');
+    }
+}
+
+return include __DIR__ . '/%s';
+`
+
+// shellProxyTemplate - shell proxy в формате Composer 2 для не-PHP bin файлов
+const shellProxyTemplate = `#!/usr/bin/env sh
+
+# Proxy shell script generated by Composer
+# This file runs the referenced bin path (%[1]s)
+
+# Support bash to support ` + "`source`" + ` with fallback on $0 if this does not run with bash
+selfArg="$BASH_SOURCE"
+if [ -z "$selfArg" ]; then
+    selfArg="$0"
+fi
+
+self=$(realpath "$selfArg" 2> /dev/null)
+if [ -z "$self" ]; then
+    self="$selfArg"
+fi
+
+dir=$(cd "${self%%[/\\]*}" > /dev/null; cd '%[2]s' && pwd)
+
+export COMPOSER_RUNTIME_BIN_DIR="$(cd "${self%%[/\\]*}" > /dev/null; pwd)"
+
+# If bash is sourcing this file, we have to source the target as well
+bashSource="$BASH_SOURCE"
+if [ -n "$bashSource" ]; then
+    if [ "$bashSource" != "$0" ]; then
+        source "${dir}/%[3]s" "$@"
+        return
+    fi
+fi
+
+exec "${dir}/%[3]s" "$@"
+`
+
+// batchProxyTemplate - .bat proxy для Windows
+const batchProxyTemplate = `@ECHO OFF
+REM Proxy batch file generated by Composer
+setlocal DISABLEDELAYEDEXPANSION
+SET BIN_TARGET=%%~dp0\%s
+SET COMPOSER_RUNTIME_BIN_DIR=%%~dp0
+%s"%%BIN_TARGET%%" %%*
+`
@@ -0,0 +1,191 @@
+package installer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xman12/go-composer/pkg/composer"
+)
+
+// ErrLocalChanges - в установленных пакетах есть изменения, которые нельзя перезаписать
+var ErrLocalChanges = errors.New("vendor packages have local changes")
+
+// stashDir - директория внутри vendor, куда сохраняются локальные изменения
+const stashDir = "composer/stash"
+
+// stash - сохраненные изменения пакета, возвращаемые после переустановки
+type stash struct {
+	name       string
+	packageDir string
+	dir        string
+	changes    []Change
+	pristine   map[string]string
+}
+
+// guardLocalChanges проверяет пакеты перед переустановкой.
+// Изменения перезаписываются, сохраняются (stash) или установка прерывается -
+// по config.discard-changes или ответу пользователя.
+func (i *Installer) guardLocalChanges(packages []composer.LockedPackage) ([]*stash, error) {
+	var (
+		stashes []*stash
+		blocked []string
+	)
+
+	for _, pkg := range packages {
+		changes, tracked, err := LocalChanges(i.vendorDir, pkg.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check local changes in %s: %w", pkg.Name, err)
+		}
+		if !tracked || len(changes) == 0 {
+			continue
+		}
+
+		fmt.Printf("  ⚠️  %s has local changes:\n", pkg.Name)
+		printChanges(changes, "      ")
+
+		action := i.discardChanges
+		if action == composer.DiscardChangesPrompt && i.interactive {
+			action = askDiscard(pkg.Name)
+		}
+
+		switch action {
+		case composer.DiscardChangesTrue:
+			fmt.Printf("  🗑️  Discarding local changes in %s\n", pkg.Name)
+		case composer.DiscardChangesStash:
+			s, err := i.stashChanges(pkg.Name, changes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to stash local changes in %s: %w", pkg.Name, err)
+			}
+			fmt.Printf("  📥 Stashed local changes in %s\n", pkg.Name)
+			stashes = append(stashes, s)
+		default:
+			blocked = append(blocked, pkg.Name)
+		}
+	}
+
+	if len(blocked) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrLocalChanges, strings.Join(blocked, ", "))
+	}
+	return stashes, nil
+}
+
+// askDiscard спрашивает, что делать с изменениями пакета
+func askDiscard(name string) string {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("    Discard changes in %s [y (discard), n (abort), s (stash)]? ", name)
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+			return composer.DiscardChangesPrompt
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return composer.DiscardChangesTrue
+		case "s", "stash":
+			return composer.DiscardChangesStash
+		case "n", "no":
+			return composer.DiscardChangesPrompt
+		}
+	}
+}
+
+// stashChanges копирует измененные и добавленные файлы пакета в vendor/composer/stash
+func (i *Installer) stashChanges(name string, changes []Change) (*stash, error) {
+	manifest, err := readManifest(i.vendorDir, name)
+	if err != nil || manifest == nil {
+		return nil, fmt.Errorf("manifest of %s is not available", name)
+	}
+
+	dir := filepath.Join(i.vendorDir, filepath.FromSlash(stashDir), filepath.FromSlash(name))
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	packageDir := manifest.dir(i.vendorDir)
+	for _, change := range changes {
+		if change.Kind == ChangeDeleted {
+			continue
+		}
+		if err := copyChangedFile(filepath.Join(packageDir, change.Path), filepath.Join(dir, change.Path)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &stash{name: name, packageDir: packageDir, dir: dir, changes: changes, pristine: manifest.Files}, nil
+}
+
+// restoreStashes возвращает сохраненные изменения в переустановленные пакеты.
+// Изменение применяется, только если файл в новой версии не отличается от исходного;
+// конфликтующие изменения остаются в stash.
+func (i *Installer) restoreStashes(stashes []*stash) {
+	for _, s := range stashes {
+		current, err := hashTree(s.packageDir)
+		if err != nil {
+			fmt.Printf("  ⚠️  Could not reapply changes to %s, they are kept in %s: %v\n", s.name, s.dir, err)
+			continue
+		}
+
+		var conflicts []Change
+		for _, change := range s.changes {
+			if current[change.Path] != s.pristine[change.Path] {
+				conflicts = append(conflicts, change)
+				continue
+			}
+
+			target := filepath.Join(s.packageDir, filepath.FromSlash(change.Path))
+			if change.Kind == ChangeDeleted {
+				err = os.Remove(target)
+			} else {
+				err = copyChangedFile(filepath.Join(s.dir, filepath.FromSlash(change.Path)), target)
+			}
+			if err != nil {
+				conflicts = append(conflicts, change)
+			}
+		}
+
+		if len(conflicts) == 0 {
+			os.RemoveAll(s.dir)
+			fmt.Printf("  📤 Reapplied local changes to %s\n", s.name)
+			continue
+		}
+
+		fmt.Printf("  ⚠️  %d change(s) in %s conflict with the new version, they are kept in %s:\n", len(conflicts), s.name, s.dir)
+		printChanges(conflicts, "      ")
+	}
+}
+
+// copyChangedFile копирует файл или symlink, создавая директории назначения
+func copyChangedFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dst)
+	}
+	return copyFile(src, dst, info.Mode().Perm())
+}
+
+// printChanges выводит изменения в формате "M path"
+func printChanges(changes []Change, indent string) {
+	for _, change := range changes {
+		fmt.Printf("%s%s %s\n", indent, change.Kind, change.Path)
+	}
+}
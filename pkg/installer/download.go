@@ -0,0 +1,436 @@
+package installer
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/xman12/go-composer/pkg/composer"
+	"github.com/xman12/go-composer/pkg/packagist"
+	"github.com/xman12/go-composer/pkg/vcs"
+)
+
+// DefaultJobs - число параллельных загрузок по умолчанию (как в Composer)
+const DefaultJobs = 12
+
+// defaultJobs возвращает лимит параллельных загрузок с учетом COMPOSER_MAX_PARALLEL_HTTP
+func defaultJobs() int {
+	if value := os.Getenv("COMPOSER_MAX_PARALLEL_HTTP"); value != "" {
+		if jobs, err := strconv.Atoi(value); err == nil && jobs > 0 {
+			return jobs
+		}
+	}
+	return DefaultJobs
+}
+
+// installPackages устанавливает пакеты пулом из i.jobs воркеров.
+// overwrite=true переустанавливает уже существующие пакеты (update),
+// иначе существующие директории пропускаются (install из lock).
+func (i *Installer) installPackages(packages []composer.LockedPackage, overwrite bool) error {
+	if err := os.MkdirAll(i.vendorDir, 0755); err != nil {
+		return err
+	}
+
+	workers := i.jobs
+	if workers <= 0 {
+		workers = defaultJobs()
+	}
+	if workers > len(packages) {
+		workers = len(packages)
+	}
+
+	bar := progressbar.Default(int64(len(packages)), "Installing")
+
+	queue := make(chan composer.LockedPackage)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []error
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pkg := range queue {
+				if err := i.installLockedPackage(pkg, overwrite); err != nil {
+					mu.Lock()
+					failed = append(failed, fmt.Errorf("failed to install %s: %w", pkg.Name, err))
+					mu.Unlock()
+				}
+				bar.Add(1)
+			}
+		}()
+	}
+
+	for _, pkg := range packages {
+		queue <- pkg
+	}
+	close(queue)
+
+	wg.Wait()
+	bar.Finish()
+
+	if len(failed) > 0 {
+		sort.Slice(failed, func(a, b int) bool {
+			return failed[a].Error() < failed[b].Error()
+		})
+		return errors.Join(failed...)
+	}
+
+	return nil
+}
+
+// installLockedPackage устанавливает пакет в vendor и сохраняет манифест его файлов
+func (i *Installer) installLockedPackage(pkg composer.LockedPackage, overwrite bool) error {
+	packageDir := i.paths.Dir(i.vendorDir, pkg)
+
+	// Проверяем, установлен ли уже пакет
+	if _, err := os.Stat(packageDir); err == nil {
+		if !overwrite {
+			return i.installBinaries(pkg) // Уже установлен, восстанавливаем только bin файлы
+		}
+	}
+
+	previous, err := readManifest(i.vendorDir, pkg.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := i.install(pkg, packageDir); err != nil {
+		return err
+	}
+
+	if previous != nil {
+		// Пакет переехал (изменились installer-paths) - старая копия больше не нужна
+		if previous.Path != "" && filepath.Clean(previous.Path) != filepath.Clean(packageDir) {
+			i.removeBinaries(previous.Path, previous.Bin)
+			os.RemoveAll(previous.Path)
+		}
+		// bin файлы, которых нет в новой версии пакета, удаляются
+		i.removeBinaries(packageDir, missingStrings(previous.Bin, pkg.Bin))
+	}
+	if err := i.installBinaries(pkg); err != nil {
+		return err
+	}
+
+	return writeManifest(i.vendorDir, pkg, packageDir)
+}
+
+// install устанавливает пакет из архива (dist) или из git (source).
+// Способ выбирается по preferred-install; если выбранный способ не сработал,
+// используется второй, когда он доступен.
+func (i *Installer) install(pkg composer.LockedPackage, packageDir string) error {
+	// Пакеты path-репозиториев связываются с локальной директорией
+	if pkg.Dist != nil && pkg.Dist.Type == "path" {
+		return installPath(pkg, packageDir)
+	}
+
+	hasDist := pkg.Dist != nil && pkg.Dist.URL != ""
+	hasSource := pkg.Source != nil && pkg.Source.Type == "git" && pkg.Source.URL != ""
+
+	if hasSource && (!hasDist || i.usesSource(pkg)) {
+		err := i.installSource(pkg, packageDir)
+		if err == nil || !hasDist {
+			return err
+		}
+		fmt.Printf("  ⚠️  Failed to install %s from source, trying dist: %v\n", pkg.Name, err)
+		return i.installDist(pkg, packageDir)
+	}
+
+	err := i.installDist(pkg, packageDir)
+	if err == nil || !hasSource {
+		return err
+	}
+	fmt.Printf("  ⚠️  Failed to install %s from dist, trying source: %v\n", pkg.Name, err)
+	return i.installSource(pkg, packageDir)
+}
+
+// usesSource определяет, нужно ли ставить пакет из git по preferred-install.
+// В режиме auto из git ставятся только dev-версии (ветки).
+func (i *Installer) usesSource(pkg composer.LockedPackage) bool {
+	switch i.preferred.For(pkg.Name) {
+	case composer.InstallSource:
+		return true
+	case composer.InstallAuto:
+		return strings.HasPrefix(pkg.Version, "dev-") || strings.HasSuffix(pkg.Version, "-dev")
+	default:
+		return false
+	}
+}
+
+// installDist скачивает (или берет из кеша) архив пакета и распаковывает его в vendor
+func (i *Installer) installDist(pkg composer.LockedPackage, packageDir string) error {
+	// Получаем архив из кеша или скачиваем его
+	archivePath, cleanup, err := i.fetchDist(pkg.Name, pkg.Dist)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Проверяем контрольную сумму (если есть)
+	if pkg.Dist.Shasum != "" {
+		if err := verifyChecksum(archivePath, pkg.Dist.Shasum); err != nil {
+			// Поврежденный архив не должен оставаться в кеше (локальные архивы не трогаем)
+			if _, local := localDistPath(pkg.Dist.URL); i.cache != nil && !local {
+				os.Remove(archivePath)
+			}
+			return fmt.Errorf("checksum mismatch for %s: %w", pkg.Name, err)
+		}
+	}
+
+	// Удаляем предыдущую версию, чтобы не оставить устаревших файлов
+	if err := os.RemoveAll(packageDir); err != nil {
+		return err
+	}
+
+	return extractArchive(archivePath, packageDir)
+}
+
+// fetchDist возвращает путь к архиву пакета, скачивая его в кеш при необходимости.
+// Архив пишется на диск потоково; cleanup удаляет временный файл, если кеш отключен.
+func (i *Installer) fetchDist(name string, dist *composer.Dist) (string, func(), error) {
+	noop := func() {}
+
+	if dist == nil || dist.URL == "" {
+		return "", noop, fmt.Errorf("no distribution URL for package %s", name)
+	}
+
+	// Локальные архивы (artifact-репозитории) используются на месте
+	if path, ok := localDistPath(dist.URL); ok {
+		if _, err := os.Stat(path); err != nil {
+			return "", noop, fmt.Errorf("archive for %s is not available: %w", name, err)
+		}
+		return path, noop, nil
+	}
+
+	if i.cache != nil {
+		archivePath := i.cache.DistPath(name, dist.Reference, dist.URL, dist.Type)
+		if i.cache.HasFile(archivePath) {
+			return archivePath, noop, nil
+		}
+		if i.offline {
+			return "", noop, fmt.Errorf("archive for %s (offline mode): %w: %s", name, packagist.ErrNotCached, archivePath)
+		}
+
+		if err := i.client.DownloadToFile(dist.URL, archivePath); err != nil {
+			return "", noop, err
+		}
+		return archivePath, noop, nil
+	}
+
+	if i.offline {
+		return "", noop, fmt.Errorf("cannot install %s in offline mode: %w: cache is disabled", name, packagist.ErrNotCached)
+	}
+
+	tmpFile, err := os.CreateTemp("", "go-composer-*.zip")
+	if err != nil {
+		return "", noop, err
+	}
+	tmpFile.Close()
+	cleanup := func() { os.Remove(tmpFile.Name()) }
+
+	if err := i.client.DownloadToFile(dist.URL, tmpFile.Name()); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+
+	return tmpFile.Name(), cleanup, nil
+}
+
+// localDistPath возвращает путь к архиву, если dist URL указывает на локальный файл
+func localDistPath(url string) (string, bool) {
+	if strings.HasPrefix(url, "file://") {
+		return filepath.FromSlash(strings.TrimPrefix(url, "file://")), true
+	}
+	if strings.Contains(url, "://") {
+		return "", false
+	}
+	return filepath.FromSlash(url), true
+}
+
+// checkOfflineCache проверяет, что все архивы есть в кеше, и перечисляет отсутствующие.
+// overwrite=true - пакеты будут переустановлены, архив нужен и уже установленным.
+func (i *Installer) checkOfflineCache(packages []composer.LockedPackage, overwrite bool) error {
+	if i.cache == nil {
+		return fmt.Errorf("offline mode requires the package cache: %w: cache is disabled", packagist.ErrNotCached)
+	}
+
+	var missing []string
+	for _, pkg := range packages {
+		// Уже установленные пакеты не требуют архива, если они не переустанавливаются
+		if _, err := os.Stat(i.paths.Dir(i.vendorDir, pkg)); err == nil && !overwrite {
+			continue
+		}
+
+		// git-пакеты берутся из зеркала в кеше
+		if pkg.Source != nil && pkg.Source.Type == "git" && (pkg.Dist == nil || i.usesSource(pkg)) {
+			continue
+		}
+
+		if pkg.Dist == nil || pkg.Dist.URL == "" {
+			missing = append(missing, fmt.Sprintf("%s %s (no dist)", pkg.Name, pkg.Version))
+			continue
+		}
+		if pkg.Dist.Type == "path" {
+			continue
+		}
+		if path, ok := localDistPath(pkg.Dist.URL); ok {
+			if _, err := os.Stat(path); err != nil {
+				missing = append(missing, fmt.Sprintf("%s %s (%s)", pkg.Name, pkg.Version, path))
+			}
+			continue
+		}
+
+		archivePath := i.cache.DistPath(pkg.Name, pkg.Dist.Reference, pkg.Dist.URL, pkg.Dist.Type)
+		if !i.cache.HasFile(archivePath) {
+			// Без архива пакет можно поставить из закешированного git зеркала
+			if pkg.Source != nil && pkg.Source.Type == "git" && vcs.NewGit(pkg.Source.URL, i.cache, true).HasMirror() {
+				continue
+			}
+			missing = append(missing, fmt.Sprintf("%s %s (%s)", pkg.Name, pkg.Version, archivePath))
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return fmt.Errorf("offline mode: %d package archive(s) %w (%s):\n  - %s",
+		len(missing), packagist.ErrNotCached, i.cache.Root(), strings.Join(missing, "\n  - "))
+}
+
+// verifyChecksum сверяет контрольную сумму файла.
+// Composer использует sha1 (40 символов), поддерживается также sha256 (64 символа).
+func verifyChecksum(path, expected string) error {
+	var h hash.Hash
+	switch len(expected) {
+	case sha1.Size * 2:
+		h = sha1.New()
+	case sha256.Size * 2:
+		h = sha256.New()
+	default:
+		return fmt.Errorf("unsupported checksum format %q", expected)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return err
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// extractArchive распаковывает zip архив в targetDir, пропуская корневую директорию архива.
+// Архивы без общей корневой директории (например, composer archive) распаковываются как есть.
+func extractArchive(archivePath, targetDir string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return err
+	}
+
+	root := archiveRoot(reader.File)
+
+	for _, file := range reader.File {
+		// Пропускаем общую корневую директорию (обычно это vendor-package-version/)
+		relativePath := strings.TrimPrefix(file.Name, root)
+		if relativePath == "" || relativePath == "/" {
+			continue
+		}
+
+		targetPath := filepath.Join(targetDir, relativePath)
+
+		// Защита от путей вида ../../ в архиве
+		if !strings.HasPrefix(targetPath, filepath.Clean(targetDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal file path in archive: %s", file.Name)
+		}
+
+		if file.FileInfo().IsDir() {
+			os.MkdirAll(targetPath, 0755)
+			continue
+		}
+
+		if err := extractFile(file, targetPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveRoot возвращает общую корневую директорию архива ("name/") или "",
+// если файлы лежат в корне архива
+func archiveRoot(files []*zip.File) string {
+	root := ""
+	for _, file := range files {
+		idx := strings.Index(file.Name, "/")
+		if idx < 0 {
+			return "" // файл в корне архива
+		}
+		dir := file.Name[:idx+1]
+		if root == "" {
+			root = dir
+		} else if dir != root {
+			return ""
+		}
+	}
+	return root
+}
+
+// extractFile копирует один файл из архива на диск
+func extractFile(file *zip.File, targetPath string) error {
+	// Создаем родительскую директорию
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return err
+	}
+
+	mode := file.Mode().Perm()
+	if mode == 0 || runtime.GOOS == "windows" {
+		mode = 0644
+	}
+
+	outFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(outFile, rc); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
@@ -1,20 +1,18 @@
 package installer
 
 import (
-	"archive/zip"
-	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io"
-	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 	"sync"
 
-	"github.com/schollz/progressbar/v3"
+	"github.com/xman12/go-composer/pkg/auth"
+	"github.com/xman12/go-composer/pkg/cache"
 	"github.com/xman12/go-composer/pkg/composer"
 	"github.com/xman12/go-composer/pkg/packagist"
+	"github.com/xman12/go-composer/pkg/repository"
 	"github.com/xman12/go-composer/pkg/resolver"
 )
 
@@ -23,6 +21,19 @@ type Installer struct {
 	client    *packagist.Client
 	resolver  *resolver.Resolver
 	vendorDir string
+	cache     *cache.Cache
+	offline   bool
+	jobs      int
+	preferred composer.PreferredInstall
+
+	discardChanges string
+	interactive    bool
+
+	binDir    string
+	binCompat string
+	binMu     sync.Mutex
+
+	paths *composer.InstallPaths
 }
 
 // NewInstaller создает новый installer
@@ -32,13 +43,79 @@ func NewInstaller(vendorDir string) *Installer {
 		client:    client,
 		resolver:  resolver.NewResolver(client),
 		vendorDir: vendorDir,
+		cache:     client.Cache,
+		jobs:      defaultJobs(),
+		preferred: composer.PreferredInstall{Default: composer.InstallDist},
+
+		discardChanges: composer.DiscardChangesPrompt,
+
+		binDir:    filepath.Join(vendorDir, "bin"),
+		binCompat: BinCompatAuto,
+	}
+}
+
+// SetCache задает кеш архивов и метаданных; nil отключает кеширование
+func (i *Installer) SetCache(c *cache.Cache) {
+	i.cache = c
+	i.client.Cache = c
+}
+
+// SetAuth задает учетные данные для метаданных и загрузки архивов
+func (i *Installer) SetAuth(store *auth.Store) {
+	i.client.Auth = store
+}
+
+// SetOffline включает режим без сети: метаданные и архивы берутся только из кеша
+func (i *Installer) SetOffline(offline bool) {
+	i.offline = offline
+	i.client.Offline = offline
+}
+
+// SetPreferredInstall задает способ установки пакетов: dist (архив) или source (git)
+func (i *Installer) SetPreferredInstall(preferred composer.PreferredInstall) {
+	i.preferred = preferred
+}
+
+// SetDiscardChanges задает поведение при локальных изменениях в vendor (true, "stash", false)
+func (i *Installer) SetDiscardChanges(mode string) {
+	i.discardChanges = mode
+}
+
+// SetInteractive разрешает спрашивать пользователя о локальных изменениях в vendor
+func (i *Installer) SetInteractive(interactive bool) {
+	i.interactive = interactive
+}
+
+// SetBinDir задает директорию для bin файлов пакетов (config.bin-dir)
+func (i *Installer) SetBinDir(dir string) {
+	i.binDir = dir
+}
+
+// SetBinCompat задает способ установки bin файлов: auto, proxy, full или symlink
+func (i *Installer) SetBinCompat(mode string) {
+	i.binCompat = mode
+}
+
+// SetJobs ограничивает число параллельных загрузок; 0 - значение по умолчанию
+func (i *Installer) SetJobs(jobs int) {
+	if jobs <= 0 {
+		jobs = defaultJobs()
 	}
+	i.jobs = jobs
+	i.resolver.SetConcurrency(jobs)
 }
 
 // Install устанавливает все зависимости
 func (i *Installer) Install(composerJSON *composer.ComposerJSON, dev bool) (*composer.ComposerLock, error) {
 	fmt.Println("📦 Resolving dependencies...")
 
+	// Метаданные берутся из repositories composer.json и packagist.org
+	repos, err := repository.New(composerJSON.Repositories, i.client)
+	if err != nil {
+		return nil, err
+	}
+	i.resolver.SetRepository(repos)
+
 	// Сначала разрешаем основные зависимости
 	mainPackages, err := i.resolver.Resolve(composerJSON.Require)
 	if err != nil {
@@ -54,8 +131,8 @@ func (i *Installer) Install(composerJSON *composer.ComposerJSON, dev bool) (*com
 	// Затем разрешаем dev зависимости (если нужно)
 	var devPackages map[string]*resolver.Package
 	if dev && len(composerJSON.RequireDev) > 0 {
-		// Создаем новый resolver для dev зависимостей
-		devResolver := resolver.NewResolver(i.client)
+		// Создаем новый resolver для dev зависимостей (метаданные уже загружены)
+		devResolver := i.resolver.Fork()
 
 		// Объединяем все требования (основные + dev)
 		allRequirements := make(map[string]string)
@@ -83,18 +160,32 @@ func (i *Installer) Install(composerJSON *composer.ComposerJSON, dev bool) (*com
 	totalPackages := len(mainPackages) + len(devPackages)
 	fmt.Printf("✅ Resolved %d packages (%d main + %d dev)\n\n", totalPackages, len(mainPackages), len(devPackages))
 
-	// Создаем vendor директорию
-	if err := os.MkdirAll(i.vendorDir, 0755); err != nil {
-		return nil, err
+	// Преобразуем разрешенные пакеты в записи lock файла
+	lockedMain := toLockedPackages(mainPackages)
+	lockedDev := toLockedPackages(devPackages)
+	allPackages := append(append([]composer.LockedPackage{}, lockedMain...), lockedDev...)
+
+	// extra.installer-paths (composer/installers) переносит пакеты из vendor
+	i.paths = composer.NewInstallPaths(composerJSON, allPackages)
+
+	// В offline режиме все архивы должны уже лежать в кеше: пакеты переустанавливаются,
+	// поэтому уже установленные тоже требуют архива
+	if i.offline {
+		if err := i.checkOfflineCache(allPackages, true); err != nil {
+			return nil, err
+		}
 	}
 
-	// Объединяем все пакеты для установки
-	allPackages := make(map[string]*resolver.Package)
-	for name, pkg := range mainPackages {
-		allPackages[name] = pkg
+	// Пакеты, которых больше нет в зависимостях, будут удалены
+	stale, err := i.stalePackages(allPackages)
+	if err != nil {
+		return nil, err
 	}
-	for name, pkg := range devPackages {
-		allPackages[name] = pkg
+
+	// Изменения, сделанные вручную в vendor, не перезаписываются молча
+	stashes, err := i.guardLocalChanges(append(append([]composer.LockedPackage{}, allPackages...), stale...))
+	if err != nil {
+		return nil, err
 	}
 
 	// Устанавливаем пакеты параллельно
@@ -103,72 +194,20 @@ func (i *Installer) Install(composerJSON *composer.ComposerJSON, dev bool) (*com
 
 	// Выводим список пакетов, которые будем устанавливать
 	for _, pkg := range allPackages {
-		version := pkg.Version
-		if pkg.Info.Dist.Dist != nil && pkg.Info.Dist.Dist.Reference != "" && pkg.Info.Dist.Dist.Reference != pkg.Version {
-			ref := pkg.Info.Dist.Dist.Reference
-			if len(ref) > 8 {
-				ref = ref[:8]
-			}
-			version = fmt.Sprintf("%s (%s)", pkg.Version, ref)
-		}
 		devMarker := ""
-		if devPackages != nil {
-			if _, isDev := devPackages[pkg.Name]; isDev {
-				devMarker = " [dev]"
-			}
+		if _, isDev := devPackages[pkg.Name]; isDev {
+			devMarker = " [dev]"
 		}
-		fmt.Printf("  📦 %-40s %s%s\n", pkg.Name, version, devMarker)
+		fmt.Printf("  📦 %-40s %s%s\n", pkg.Name, displayVersion(pkg), devMarker)
 	}
 	fmt.Println()
 
-	var wg sync.WaitGroup
-	errors := make(chan error, len(allPackages))
-	type lockedResult struct {
-		pkg   *composer.LockedPackage
-		isDev bool
-	}
-	lockedPackages := make(chan lockedResult, len(allPackages))
-
-	bar := progressbar.Default(int64(len(allPackages)), "Installing")
-
-	for _, pkg := range allPackages {
-		wg.Add(1)
-		isDev := false
-		if devPackages != nil {
-			_, isDev = devPackages[pkg.Name]
-		}
-		go func(pkg *resolver.Package, isDev bool) {
-			defer wg.Done()
-			defer bar.Add(1)
-
-			locked, err := i.installPackage(pkg)
-			if err != nil {
-				errors <- fmt.Errorf("failed to install %s: %w", pkg.Name, err)
-				return
-			}
-			lockedPackages <- lockedResult{pkg: locked, isDev: isDev}
-		}(pkg, isDev)
-	}
-
-	wg.Wait()
-	close(errors)
-	close(lockedPackages)
-	bar.Finish()
-
-	// Проверяем ошибки
-	if len(errors) > 0 {
-		return nil, <-errors
+	// При update пакеты переустанавливаются поверх существующих
+	if err := i.installPackages(allPackages, true); err != nil {
+		return nil, err
 	}
-
-	// Собираем locked пакеты с разделением на main и dev
-	var lockedMain []composer.LockedPackage
-	var lockedDev []composer.LockedPackage
-	for result := range lockedPackages {
-		if result.isDev {
-			lockedDev = append(lockedDev, *result.pkg)
-		} else {
-			lockedMain = append(lockedMain, *result.pkg)
-		}
+	if err := i.removePackages(stale); err != nil {
+		return nil, err
 	}
 
 	// Создаем composer.lock
@@ -179,38 +218,27 @@ func (i *Installer) Install(composerJSON *composer.ComposerJSON, dev bool) (*com
 
 	fmt.Println("\n✅ All packages installed successfully!")
 
+	// Возвращаем сохраненные изменения поверх новых версий пакетов
+	i.restoreStashes(stashes)
+
 	return lock, nil
 }
 
-// installPackage устанавливает один пакет
-func (i *Installer) installPackage(pkg *resolver.Package) (*composer.LockedPackage, error) {
-	// Проверяем, есть ли dist
-	if pkg.Info.Dist.Dist == nil || pkg.Info.Dist.Dist.URL == "" {
-		return nil, fmt.Errorf("no distribution URL for package %s", pkg.Name)
-	}
-
-	// Загружаем пакет
-	data, err := i.client.DownloadPackage(pkg.Info.Dist.Dist.URL)
-	if err != nil {
-		return nil, err
-	}
-
-	// Проверяем shasum если есть
-	if pkg.Info.Dist.Dist.Shasum != "" {
-		hash := sha256.Sum256(data)
-		actualSum := hex.EncodeToString(hash[:])
-		if actualSum != pkg.Info.Dist.Dist.Shasum {
-			return nil, fmt.Errorf("shasum mismatch for %s", pkg.Name)
-		}
-	}
-
-	// Распаковываем zip
-	if err := i.extractZip(data, pkg.Name); err != nil {
-		return nil, err
+// toLockedPackages создает записи lock файла для разрешенных пакетов (по имени)
+func toLockedPackages(packages map[string]*resolver.Package) []composer.LockedPackage {
+	locked := make([]composer.LockedPackage, 0, len(packages))
+	for _, pkg := range packages {
+		locked = append(locked, *newLockedPackage(pkg))
 	}
+	sort.Slice(locked, func(a, b int) bool {
+		return locked[a].Name < locked[b].Name
+	})
+	return locked
+}
 
-	// Создаем LockedPackage
-	locked := &composer.LockedPackage{
+// newLockedPackage создает запись lock файла для разрешенного пакета
+func newLockedPackage(pkg *resolver.Package) *composer.LockedPackage {
+	return &composer.LockedPackage{
 		Name:        pkg.Name,
 		Version:     pkg.Version,
 		Source:      convertSource(pkg.Info.Source),
@@ -219,6 +247,8 @@ func (i *Installer) installPackage(pkg *resolver.Package) (*composer.LockedPacka
 		RequireDev:  map[string]string(pkg.Info.RequireDev),
 		Type:        pkg.Info.Type,
 		Autoload:    convertAutoload(pkg.Info.Autoload),
+		Bin:         []string(pkg.Info.Bin),
+		Extra:       pkg.Info.Extra,
 		License:     pkg.Info.License,
 		Authors:     convertAuthors(pkg.Info.Authors),
 		Description: pkg.Info.Description,
@@ -227,66 +257,27 @@ func (i *Installer) installPackage(pkg *resolver.Package) (*composer.LockedPacka
 		Time:        pkg.Info.Time,
 		Support:     pkg.Info.Support,
 		Funding:     []map[string]string(pkg.Info.Funding),
-	}
 
-	return locked, nil
+		TransportOptions: pkg.Info.TransportOptions,
+	}
 }
 
-// extractZip распаковывает zip архив в vendor
-func (i *Installer) extractZip(data []byte, packageName string) error {
-	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
-	if err != nil {
-		return err
+// displayVersion форматирует версию пакета с коротким reference для вывода
+func displayVersion(pkg composer.LockedPackage) string {
+	var ref string
+	switch {
+	case pkg.Dist != nil:
+		ref = pkg.Dist.Reference
+	case pkg.Source != nil:
+		ref = pkg.Source.Reference
 	}
-
-	// Путь для установки пакета
-	targetDir := filepath.Join(i.vendorDir, packageName)
-
-	for _, file := range reader.File {
-		// Пропускаем корневую директорию в архиве
-		parts := strings.Split(file.Name, "/")
-		if len(parts) < 2 {
-			continue
-		}
-		relativePath := strings.Join(parts[1:], "/")
-		if relativePath == "" {
-			continue
-		}
-
-		targetPath := filepath.Join(targetDir, relativePath)
-
-		if file.FileInfo().IsDir() {
-			os.MkdirAll(targetPath, file.Mode())
-			continue
-		}
-
-		// Создаем директории
-		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-			return err
-		}
-
-		// Извлекаем файл
-		outFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
-		if err != nil {
-			return err
-		}
-
-		rc, err := file.Open()
-		if err != nil {
-			outFile.Close()
-			return err
-		}
-
-		_, err = io.Copy(outFile, rc)
-		rc.Close()
-		outFile.Close()
-
-		if err != nil {
-			return err
-		}
+	if ref == "" || ref == pkg.Version {
+		return pkg.Version
 	}
-
-	return nil
+	if len(ref) > 8 {
+		ref = ref[:8]
+	}
+	return fmt.Sprintf("%s (%s)", pkg.Version, ref)
 }
 
 // calculateContentHash вычисляет хеш для composer.json
@@ -331,10 +322,29 @@ func convertAutoload(autoload packagist.AutoloadConfig) composer.AutoloadConfig
 	if psr0, ok := autoload["psr-0"].(map[string]interface{}); ok {
 		config.PSR0 = psr0
 	}
+	config.Classmap = stringList(autoload["classmap"])
+	config.Files = stringList(autoload["files"])
+	config.ExcludeFromClassmap = stringList(autoload["exclude-from-classmap"])
 
 	return config
 }
 
+// stringList преобразует JSON массив строк
+func stringList(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if str, ok := item.(string); ok {
+			list = append(list, str)
+		}
+	}
+	return list
+}
+
 func convertAuthors(authors []packagist.Author) []composer.Author {
 	result := make([]composer.Author, len(authors))
 	for i, a := range authors {
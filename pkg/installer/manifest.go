@@ -0,0 +1,216 @@
+package installer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/xman12/go-composer/pkg/composer"
+)
+
+// manifestDir - директория манифестов установленных пакетов внутри vendor
+const manifestDir = "composer/manifests"
+
+// Виды локальных изменений файла пакета
+const (
+	ChangeModified = "M"
+	ChangeAdded    = "A"
+	ChangeDeleted  = "D"
+)
+
+// Manifest - хеши файлов пакета сразу после установки.
+// По нему определяется, что файлы в vendor были изменены вручную.
+// Для symlink'ов (path-репозитории) хеши не ведутся: файлы принадлежат проекту,
+// а манифест нужен, чтобы при удалении пакета найти его директорию и bin файлы.
+type Manifest struct {
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Reference string            `json:"reference,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	Bin       []string          `json:"bin,omitempty"`
+	Symlink   bool              `json:"symlink,omitempty"`
+	Files     map[string]string `json:"files,omitempty"`
+}
+
+// Change - локальное изменение файла пакета (путь относительно директории пакета)
+type Change struct {
+	Kind string
+	Path string
+}
+
+// LocalChanges сравнивает файлы установленного пакета с его манифестом.
+// tracked=false, если пакет установлен symlink'ом или манифеста нет (пакет установлен
+// старой версией), а также если директория пакета удалена: такой пакет считается неустановленным.
+func LocalChanges(vendorDir, name string) (changes []Change, tracked bool, err error) {
+	manifest, err := readManifest(vendorDir, name)
+	if err != nil || manifest == nil || manifest.Symlink {
+		return nil, false, err
+	}
+
+	dir := manifest.dir(vendorDir)
+	if _, err := os.Lstat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+
+	files, err := hashTree(dir)
+	if err != nil {
+		return nil, true, err
+	}
+
+	return diffFiles(manifest.Files, files), true, nil
+}
+
+// writeManifest сохраняет хеши только что установленного пакета
+// (для symlink'а - только путь и bin файлы)
+func writeManifest(vendorDir string, pkg composer.LockedPackage, packageDir string) error {
+	path := manifestPath(vendorDir, pkg.Name)
+
+	info, err := os.Lstat(packageDir)
+	if err != nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	manifest := Manifest{Name: pkg.Name, Version: pkg.Version, Path: filepath.ToSlash(packageDir), Bin: pkg.Bin}
+	if info.Mode()&os.ModeSymlink != 0 {
+		manifest.Symlink = true
+	} else if manifest.Files, err = hashTree(packageDir); err != nil {
+		return err
+	}
+	if pkg.Dist != nil {
+		manifest.Reference = pkg.Dist.Reference
+	}
+	if manifest.Reference == "" && pkg.Source != nil {
+		manifest.Reference = pkg.Source.Reference
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// readManifest читает манифест пакета; nil, если его нет
+func readManifest(vendorDir, name string) (*Manifest, error) {
+	data, err := os.ReadFile(manifestPath(vendorDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// dir возвращает директорию установленного пакета
+// (манифесты без path созданы до поддержки installer-paths)
+func (m *Manifest) dir(vendorDir string) string {
+	if m.Path != "" {
+		return filepath.FromSlash(m.Path)
+	}
+	return filepath.Join(vendorDir, m.Name)
+}
+
+// manifestPath возвращает путь к манифесту пакета
+func manifestPath(vendorDir, name string) string {
+	return filepath.Join(vendorDir, filepath.FromSlash(manifestDir), filepath.FromSlash(name)+".json")
+}
+
+// hashTree вычисляет sha1 всех файлов директории (ключи - пути через "/").
+// Служебная директория .git source-установок не учитывается.
+func hashTree(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		sum, err := hashEntry(path, entry)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+
+	return files, err
+}
+
+// hashEntry хеширует содержимое файла или цель symlink
+func hashEntry(path string, entry fs.DirEntry) (string, error) {
+	hash := sha1.New()
+
+	if entry.Type()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(hash, "symlink:"+target)
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// diffFiles сравнивает хеши файлов из манифеста с текущими
+func diffFiles(pristine, current map[string]string) []Change {
+	var changes []Change
+
+	for path, sum := range current {
+		original, ok := pristine[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeAdded, Path: path})
+		case original != sum:
+			changes = append(changes, Change{Kind: ChangeModified, Path: path})
+		}
+	}
+	for path := range pristine {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, Change{Kind: ChangeDeleted, Path: path})
+		}
+	}
+
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Path < changes[b].Path
+	})
+	return changes
+}
@@ -0,0 +1,113 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xman12/go-composer/pkg/composer"
+)
+
+func TestLocalChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		change      func(packageDir string) error
+		wantTracked bool
+		want        []Change
+	}{
+		{
+			name:        "pristine",
+			change:      func(string) error { return nil },
+			wantTracked: true,
+		},
+		{
+			name: "modified and added",
+			change: func(dir string) error {
+				if err := os.WriteFile(filepath.Join(dir, "src", "A.php"), []byte("<?php // changed"), 0644); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0644)
+			},
+			wantTracked: true,
+			want:        []Change{{ChangeAdded, "new.txt"}, {ChangeModified, "src/A.php"}},
+		},
+		{
+			name:        "deleted file",
+			change:      func(dir string) error { return os.Remove(filepath.Join(dir, "README.md")) },
+			wantTracked: true,
+			want:        []Change{{ChangeDeleted, "README.md"}},
+		},
+		{
+			name:        "deleted package directory",
+			change:      os.RemoveAll,
+			wantTracked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vendorDir := t.TempDir()
+			packageDir := filepath.Join(vendorDir, "acme", "lib")
+			if err := os.MkdirAll(filepath.Join(packageDir, "src"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			os.WriteFile(filepath.Join(packageDir, "README.md"), []byte("readme"), 0644)
+			os.WriteFile(filepath.Join(packageDir, "src", "A.php"), []byte("<?php"), 0644)
+
+			pkg := composer.LockedPackage{Name: "acme/lib", Version: "1.0.0"}
+			if err := writeManifest(vendorDir, pkg, packageDir); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.change(packageDir); err != nil {
+				t.Fatal(err)
+			}
+
+			changes, tracked, err := LocalChanges(vendorDir, pkg.Name)
+			if err != nil {
+				t.Fatalf("LocalChanges: %v", err)
+			}
+			if tracked != tt.wantTracked {
+				t.Errorf("tracked = %v, want %v", tracked, tt.wantTracked)
+			}
+			if len(changes) != len(tt.want) {
+				t.Fatalf("changes = %v, want %v", changes, tt.want)
+			}
+			for i := range changes {
+				if changes[i] != tt.want[i] {
+					t.Errorf("changes = %v, want %v", changes, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteManifestSymlink(t *testing.T) {
+	vendorDir := t.TempDir()
+	target := t.TempDir()
+	os.WriteFile(filepath.Join(target, "tool"), []byte("#!/bin/sh"), 0755)
+
+	packageDir := filepath.Join(vendorDir, "acme", "tool")
+	os.MkdirAll(filepath.Dir(packageDir), 0755)
+	if err := os.Symlink(target, packageDir); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	pkg := composer.LockedPackage{Name: "acme/tool", Version: "1.0.0", Bin: []string{"tool"}}
+	if err := writeManifest(vendorDir, pkg, packageDir); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := readManifest(vendorDir, pkg.Name)
+	if err != nil || manifest == nil {
+		t.Fatalf("readManifest = %v, %v", manifest, err)
+	}
+	if !manifest.Symlink || manifest.Files != nil || manifest.dir(vendorDir) != packageDir || len(manifest.Bin) != 1 {
+		t.Errorf("manifest = %+v, want symlink with path and bin and no files", manifest)
+	}
+
+	// Файлы symlink'а принадлежат проекту: их изменения не отслеживаются
+	os.WriteFile(filepath.Join(target, "tool"), []byte("changed"), 0755)
+	if changes, tracked, err := LocalChanges(vendorDir, pkg.Name); err != nil || tracked || len(changes) > 0 {
+		t.Errorf("LocalChanges = %v, %v, %v; want untracked", changes, tracked, err)
+	}
+}
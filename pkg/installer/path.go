@@ -0,0 +1,121 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/xman12/go-composer/pkg/composer"
+)
+
+// installPath устанавливает пакет path-репозитория.
+// transport-options.symlink: true - только symlink, false - копия директории,
+// не задан - symlink с откатом на копию. relative (по умолчанию true) задает
+// относительный путь ссылки, чтобы проект можно было переносить.
+func installPath(pkg composer.LockedPackage, packageDir string) error {
+	source := filepath.FromSlash(pkg.Dist.URL)
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		return fmt.Errorf("path %s for package %s does not exist", pkg.Dist.URL, pkg.Name)
+	}
+
+	if err := os.RemoveAll(packageDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(packageDir), 0755); err != nil {
+		return err
+	}
+
+	symlink, hasSymlink := pkg.TransportOptions["symlink"].(bool)
+	relative, hasRelative := pkg.TransportOptions["relative"].(bool)
+	if !hasRelative {
+		relative = true
+	}
+
+	if !hasSymlink || symlink {
+		err := linkPath(source, packageDir, relative)
+		if err == nil {
+			return nil
+		}
+		if hasSymlink {
+			return fmt.Errorf("failed to symlink %s: %w", pkg.Dist.URL, err)
+		}
+		// symlink недоступен (например, Windows без прав) - копируем
+	}
+
+	return copyDir(source, packageDir)
+}
+
+// linkPath создает symlink packageDir -> source
+func linkPath(source, packageDir string, relative bool) error {
+	target, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+
+	if relative {
+		linkDir, err := filepath.Abs(filepath.Dir(packageDir))
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(linkDir, target); err == nil {
+			target = rel
+		}
+	}
+
+	return os.Symlink(target, packageDir)
+}
+
+// copyDir рекурсивно копирует директорию src в dst
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyFile копирует файл с сохранением прав доступа
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,102 @@
+package installer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xman12/go-composer/pkg/composer"
+)
+
+// stalePackages возвращает пакеты, установленные ранее (есть манифест),
+// но отсутствующие среди новых зависимостей
+func (i *Installer) stalePackages(packages []composer.LockedPackage) ([]composer.LockedPackage, error) {
+	keep := make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		keep[pkg.Name] = true
+	}
+
+	root := filepath.Join(i.vendorDir, filepath.FromSlash(manifestDir))
+	var stale []composer.LockedPackage
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ".json")
+		if !keep[name] {
+			stale = append(stale, composer.LockedPackage{Name: name})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installed packages: %w", err)
+	}
+
+	sort.Slice(stale, func(a, b int) bool {
+		return stale[a].Name < stale[b].Name
+	})
+	return stale, nil
+}
+
+// removePackages удаляет пакеты из vendor вместе с их bin файлами и манифестами
+func (i *Installer) removePackages(packages []composer.LockedPackage) error {
+	for _, pkg := range packages {
+		manifest, err := readManifest(i.vendorDir, pkg.Name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("  🗑️  Removing %s\n", pkg.Name)
+		packageDir := filepath.Join(i.vendorDir, pkg.Name)
+		if manifest != nil {
+			packageDir = manifest.dir(i.vendorDir)
+			i.removeBinaries(packageDir, manifest.Bin)
+		}
+
+		if err := os.RemoveAll(packageDir); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", pkg.Name, err)
+		}
+		if err := os.Remove(manifestPath(i.vendorDir, pkg.Name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		// Пустая директория вендора (vendor/acme) больше не нужна
+		if filepath.Clean(packageDir) == filepath.Join(i.vendorDir, pkg.Name) {
+			os.Remove(filepath.Dir(packageDir))
+		}
+		os.Remove(filepath.Dir(manifestPath(i.vendorDir, pkg.Name)))
+	}
+
+	return nil
+}
+
+// missingStrings возвращает элементы old, которых нет в current
+func missingStrings(old, current []string) []string {
+	present := make(map[string]bool, len(current))
+	for _, value := range current {
+		present[value] = true
+	}
+
+	var missing []string
+	for _, value := range old {
+		if !present[value] {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
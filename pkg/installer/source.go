@@ -0,0 +1,13 @@
+package installer
+
+import (
+	"github.com/xman12/go-composer/pkg/composer"
+	"github.com/xman12/go-composer/pkg/vcs"
+)
+
+// installSource устанавливает пакет клонированием git репозитория
+// и переключением на зафиксированный в lock коммит
+func (i *Installer) installSource(pkg composer.LockedPackage, packageDir string) error {
+	git := vcs.NewGit(pkg.Source.URL, i.cache, i.offline)
+	return git.Checkout(packageDir, pkg.Source.Reference)
+}
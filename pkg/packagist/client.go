@@ -2,10 +2,19 @@ package packagist
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/xman12/go-composer/pkg/auth"
+	"github.com/xman12/go-composer/pkg/cache"
 )
 
 // Requirements представляет гибкий тип для require/require-dev
@@ -87,6 +96,28 @@ func (f *FlexibleMap) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// StringList представляет значение, которое может быть строкой или массивом строк (bin)
+type StringList []string
+
+// UnmarshalJSON позволяет парсить как строку, так и массив
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err == nil {
+		*l = arr
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil && s != "" {
+		*l = StringList{s}
+		return nil
+	}
+
+	// Если ничего не подошло - возвращаем пустой список
+	*l = StringList{}
+	return nil
+}
+
 const (
 	DefaultPackagistURL = "https://repo.packagist.org"
 )
@@ -95,43 +126,70 @@ const (
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
+	// Cache хранит метаданные пакетов; nil отключает кеширование
+	Cache *cache.Cache
+	// Offline запрещает сетевые запросы: метаданные читаются только из кеша
+	Offline bool
+	// MaxRetries - число повторов при временных ошибках (таймауты, 429, 5xx)
+	MaxRetries int
+	// RetryDelay - базовая задержка экспоненциального backoff
+	RetryDelay time.Duration
+	// ReadTimeout - максимальная пауза в получении данных ответа
+	ReadTimeout time.Duration
+	// Auth добавляет учетные данные из auth.json к запросам по хосту
+	Auth *auth.Store
 }
 
 // NewClient создает новый клиент Packagist
 func NewClient() *Client {
-	return &Client{
-		BaseURL: DefaultPackagistURL,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	client := &Client{
+		BaseURL:     DefaultPackagistURL,
+		Cache:       cache.New(cache.DefaultDir()),
+		MaxRetries:  DefaultMaxRetries,
+		RetryDelay:  DefaultRetryDelay,
+		ReadTimeout: DefaultReadTimeout,
 	}
+	client.HTTPClient = client.newHTTPClient(DefaultConnectTimeout)
+	client.applyEnvironment()
+	return client
 }
 
 // PackageInfo содержит информацию о пакете из Packagist
 type PackageInfo struct {
 	Packages map[string][]PackageVersion `json:"packages"`
+	Minified string                      `json:"minified,omitempty"`
 }
 
+// MinifiedComposer2 - маркер минифицированных метаданных Packagist v2
+const MinifiedComposer2 = "composer/2.0"
+
+// unsetMarker отмечает поле, удаленное относительно предыдущей версии
+const unsetMarker = "__unset"
+
 // PackageVersion представляет конкретную версию пакета
 type PackageVersion struct {
-	Name              string            `json:"name"`
-	Version           string            `json:"version"`
-	VersionNormalized string            `json:"version_normalized,omitempty"`
-	Description       string            `json:"description,omitempty"`
-	Keywords          []string          `json:"keywords,omitempty"`
-	Homepage          string            `json:"homepage,omitempty"`
-	Type              string            `json:"type,omitempty"`
-	License           []string          `json:"license,omitempty"`
-	Authors           []Author          `json:"authors,omitempty"`
-	Source            *Source           `json:"source,omitempty"`
-	Dist              FlexibleDist      `json:"dist,omitempty"`
-	Require           Requirements      `json:"require,omitempty"`
-	RequireDev        Requirements      `json:"require-dev,omitempty"`
-	Replace           FlexibleMap       `json:"replace,omitempty"`
-	Autoload          AutoloadConfig    `json:"autoload,omitempty"`
-	Time              string            `json:"time,omitempty"`
-	Support           map[string]string `json:"support,omitempty"`
-	Funding           FundingInfo       `json:"funding,omitempty"`
+	Name              string                 `json:"name"`
+	Version           string                 `json:"version"`
+	VersionNormalized string                 `json:"version_normalized,omitempty"`
+	Description       string                 `json:"description,omitempty"`
+	Keywords          []string               `json:"keywords,omitempty"`
+	Homepage          string                 `json:"homepage,omitempty"`
+	Type              string                 `json:"type,omitempty"`
+	License           []string               `json:"license,omitempty"`
+	Authors           []Author               `json:"authors,omitempty"`
+	Source            *Source                `json:"source,omitempty"`
+	Dist              FlexibleDist           `json:"dist,omitempty"`
+	Require           Requirements           `json:"require,omitempty"`
+	RequireDev        Requirements           `json:"require-dev,omitempty"`
+	Replace           FlexibleMap            `json:"replace,omitempty"`
+	Autoload          AutoloadConfig         `json:"autoload,omitempty"`
+	Bin               StringList             `json:"bin,omitempty"`
+	Extra             map[string]interface{} `json:"extra,omitempty"`
+	Time              string                 `json:"time,omitempty"`
+	Support           map[string]string      `json:"support,omitempty"`
+	Funding           FundingInfo            `json:"funding,omitempty"`
+	// TransportOptions - options path-репозитория (symlink, relative)
+	TransportOptions map[string]interface{} `json:"transport-options,omitempty"`
 }
 
 // Author представляет автора пакета
@@ -183,49 +241,312 @@ func (f *FlexibleDist) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// GetPackage получает информацию о пакете
+// GetPackage получает информацию о пакете (теги и стабильные версии).
+// Если метаданные есть в кеше, выполняется условный запрос (If-Modified-Since /
+// If-None-Match), и при ответе 304 используется закешированная копия.
 func (c *Client) GetPackage(name string) (*PackageInfo, error) {
-	url := fmt.Sprintf("%s/p2/%s.json", c.BaseURL, name)
+	return c.fetchMetadata(name)
+}
+
+// GetDevPackage получает dev-версии (ветки) пакета из /p2/<name>~dev.json.
+// Пакет без веток возвращается с пустым списком версий.
+func (c *Client) GetDevPackage(name string) (*PackageInfo, error) {
+	info, err := c.fetchMetadata(name + "~dev")
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return &PackageInfo{Packages: map[string][]PackageVersion{}}, nil
+	}
+	return info, nil
+}
+
+// fetchMetadata загружает /p2/<file>.json с учетом кеша.
+// Для файлов ~dev отсутствие (404) не считается ошибкой и возвращает nil.
+func (c *Client) fetchMetadata(file string) (*PackageInfo, error) {
+	name := strings.TrimSuffix(file, "~dev")
+	isDev := name != file
 
-	resp, err := c.HTTPClient.Get(url)
+	body, err := c.Fetch(fmt.Sprintf("%s/p2/%s.json", c.BaseURL, file), c.BaseURL, file)
 	if err != nil {
+		if isDev && (c.Offline || errors.Is(err, ErrNotFound)) {
+			return nil, nil
+		}
 		return nil, fmt.Errorf("failed to fetch package %s: %w", name, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("packagist returned status %d for package %s", resp.StatusCode, name)
+	return ParsePackages(body)
+}
+
+// Fetch загружает JSON файл репозитория с учетом кеша.
+// Файл кешируется под ключом (repoURL, key); если он уже есть в кеше,
+// выполняется условный запрос (If-Modified-Since / If-None-Match), и при ответе
+// 304 возвращается закешированная копия. В offline режиме файл читается только из кеша.
+func (c *Client) Fetch(url, repoURL, key string) ([]byte, error) {
+	if c.Offline {
+		return c.readCached(repoURL, key)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var cached *cache.RepoEntry
+	if c.Cache != nil {
+		if entry, err := c.Cache.ReadRepo(repoURL, key); err == nil {
+			cached = entry
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+		}
+	}
+
+	var (
+		status int
+		header http.Header
+		body   []byte
+	)
+	err = c.withRetry(func() error {
+		resp, err := c.send(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		status, header = resp.StatusCode, resp.Header
+		if status != http.StatusOK {
+			return nil
+		}
+
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return classifyNetError(url, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Файл не изменился - используем кеш
+	if status == http.StatusNotModified {
+		if cached == nil {
+			return nil, fmt.Errorf("%s returned 304 without cached metadata", url)
+		}
+		return cached.Data, nil
+	}
+
+	if status != http.StatusOK {
+		return nil, newStatusError(url, status)
+	}
+
+	// Сохраняем файл для ревалидации и offline запусков
+	if c.Cache != nil {
+		c.Cache.WriteRepo(repoURL, key, &cache.RepoEntry{
+			Data:         body,
+			LastModified: header.Get("Last-Modified"),
+			ETag:         header.Get("ETag"),
+		})
+	}
+
+	return body, nil
+}
+
+// readCached читает файл репозитория только из кеша
+func (c *Client) readCached(repoURL, key string) ([]byte, error) {
+	if c.Cache == nil {
+		return nil, fmt.Errorf("cannot load %s in offline mode: %w: cache is disabled", key, ErrNotCached)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	entry, err := c.Cache.ReadRepo(repoURL, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("cannot load %s in offline mode: %w: %w", key, ErrNotCached, err)
 	}
 
-	var info PackageInfo
-	if err := json.Unmarshal(body, &info); err != nil {
+	return entry.Data, nil
+}
+
+// ParsePackages разбирает секцию "packages" файла метаданных репозитория.
+// Поддерживаются формат Composer 2 (/p2: список версий, возможно минифицированный)
+// и формат Composer 1 (packages.json, providers: объект "версия -> пакет").
+func ParsePackages(body []byte) (*PackageInfo, error) {
+	var raw struct {
+		Packages map[string]json.RawMessage `json:"packages"`
+		Minified string                     `json:"minified"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		// Пустой список пакетов ("packages": []) приходит массивом
+		var empty struct {
+			Packages []json.RawMessage `json:"packages"`
+		}
+		if json.Unmarshal(body, &empty) == nil && len(empty.Packages) == 0 {
+			return &PackageInfo{Packages: map[string][]PackageVersion{}}, nil
+		}
 		return nil, fmt.Errorf("failed to parse package info: %w", err)
 	}
 
-	return &info, nil
+	info := &PackageInfo{
+		Packages: make(map[string][]PackageVersion, len(raw.Packages)),
+		Minified: raw.Minified,
+	}
+
+	for name, data := range raw.Packages {
+		versions, err := rawVersions(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse package info for %s: %w", name, err)
+		}
+		if raw.Minified == MinifiedComposer2 {
+			versions = expandMinified(versions)
+		}
+
+		parsed := make([]PackageVersion, 0, len(versions))
+		for _, fields := range versions {
+			data, err := json.Marshal(fields)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse package info: %w", err)
+			}
+
+			var version PackageVersion
+			if err := json.Unmarshal(data, &version); err != nil {
+				return nil, fmt.Errorf("failed to parse package info for %s: %w", name, err)
+			}
+			if version.Name == "" {
+				version.Name = name
+			}
+			parsed = append(parsed, version)
+		}
+		info.Packages[name] = parsed
+	}
+
+	return info, nil
+}
+
+// rawVersions возвращает версии пакета из списка (Composer 2)
+// или из объекта "версия -> пакет" (Composer 1), отсортированного по версии
+func rawVersions(data json.RawMessage) ([]map[string]json.RawMessage, error) {
+	var list []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+
+	var byVersion map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(data, &byVersion); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(byVersion))
+	for key := range byVersion {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return newerVersionKey(keys[a], keys[b])
+	})
+
+	list = make([]map[string]json.RawMessage, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, byVersion[key])
+	}
+	return list, nil
+}
+
+// newerVersionKey сравнивает ключи версий Composer 1: теги - по номеру версии
+// от новых к старым ("1.10" новее "1.9"), ветки и прочие ключи без номера - после них
+func newerVersionKey(a, b string) bool {
+	va, errA := semver.NewVersion(strings.TrimPrefix(a, "v"))
+	vb, errB := semver.NewVersion(strings.TrimPrefix(b, "v"))
+	switch {
+	case errA == nil && errB == nil:
+		if c := va.Compare(vb); c != 0 {
+			return c > 0
+		}
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	}
+	return a > b
+}
+
+// expandMinified восстанавливает полные версии из минифицированного списка:
+// каждая версия содержит только поля, изменившиеся относительно предыдущей,
+// а "__unset" означает, что поле нужно удалить.
+func expandMinified(versions []map[string]json.RawMessage) []map[string]json.RawMessage {
+	expanded := make([]map[string]json.RawMessage, 0, len(versions))
+	var current map[string]json.RawMessage
+
+	for _, fields := range versions {
+		next := make(map[string]json.RawMessage, len(current)+len(fields))
+		for key, value := range current {
+			next[key] = value
+		}
+
+		for key, value := range fields {
+			var marker string
+			if json.Unmarshal(value, &marker) == nil && marker == unsetMarker {
+				delete(next, key)
+				continue
+			}
+			next[key] = value
+		}
+
+		expanded = append(expanded, next)
+		current = next
+	}
+
+	return expanded
 }
 
-// DownloadPackage загружает дистрибутив пакета
-func (c *Client) DownloadPackage(url string) ([]byte, error) {
-	resp, err := c.HTTPClient.Get(url)
+// DownloadToFile потоково загружает дистрибутив пакета в файл dest.
+// Данные пишутся во временный файл рядом с dest и переименовываются после
+// успешной загрузки, поэтому прерванная загрузка не оставляет битый архив.
+func (c *Client) DownloadToFile(url, dest string) error {
+	if c.Offline {
+		return fmt.Errorf("cannot download %s in offline mode: %w", url, ErrNotCached)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download package: %w", err)
+		return fmt.Errorf("failed to download package: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download returned status %d", resp.StatusCode)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	err = c.withRetry(func() error {
+		resp, err := c.send(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return newStatusError(url, resp.StatusCode)
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+
+		if _, err := io.Copy(tmp, resp.Body); err != nil {
+			tmp.Close()
+			return classifyNetError(url, err)
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+
+		return os.Rename(tmp.Name(), dest)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read package data: %w", err)
+		return fmt.Errorf("failed to download package: %w", err)
 	}
 
-	return data, nil
+	return nil
 }
@@ -0,0 +1,87 @@
+package packagist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePackagesMinified(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		// want - ожидаемые версии в виде "версия require description"
+		want []string
+	}{
+		{
+			name: "not minified",
+			body: `{"packages": {"acme/lib": [
+				{"version": "2.0.0", "require": {"php": ">=8.1"}, "description": "Lib"},
+				{"version": "1.0.0", "description": "Old lib"}
+			]}}`,
+			want: []string{"2.0.0 php>=8.1 Lib", "1.0.0 - Old lib"},
+		},
+		{
+			name: "inherited fields",
+			body: `{"minified": "composer/2.0", "packages": {"acme/lib": [
+				{"name": "acme/lib", "version": "2.0.0", "require": {"php": ">=8.1"}, "description": "Lib"},
+				{"version": "1.1.0"},
+				{"version": "1.0.0", "description": "Old lib"}
+			]}}`,
+			want: []string{"2.0.0 php>=8.1 Lib", "1.1.0 php>=8.1 Lib", "1.0.0 php>=8.1 Old lib"},
+		},
+		{
+			name: "unset field",
+			body: `{"minified": "composer/2.0", "packages": {"acme/lib": [
+				{"name": "acme/lib", "version": "2.0.0", "require": {"php": ">=8.1"}, "description": "Lib"},
+				{"version": "1.0.0", "require": "__unset"},
+				{"version": "0.9.0"}
+			]}}`,
+			want: []string{"2.0.0 php>=8.1 Lib", "1.0.0 - Lib", "0.9.0 - Lib"},
+		},
+		{
+			name: "unset field restored later",
+			body: `{"minified": "composer/2.0", "packages": {"acme/lib": [
+				{"name": "acme/lib", "version": "3.0.0", "description": "Lib"},
+				{"version": "2.0.0", "description": "__unset"},
+				{"version": "1.0.0", "description": "Restored"}
+			]}}`,
+			want: []string{"3.0.0 - Lib", "2.0.0 - ", "1.0.0 - Restored"},
+		},
+		{
+			name: "composer 1 versions sorted by version",
+			body: `{"packages": {"acme/lib": {
+				"1.9.0": {"version": "1.9.0"},
+				"1.10.0": {"version": "1.10.0"},
+				"dev-main": {"version": "dev-main"},
+				"v1.2.0": {"version": "v1.2.0"},
+				"1.10.0-beta1": {"version": "1.10.0-beta1"}
+			}}}`,
+			want: []string{"1.10.0 - ", "1.10.0-beta1 - ", "1.9.0 - ", "v1.2.0 - ", "dev-main - "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParsePackages([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, version := range info.Packages["acme/lib"] {
+				if version.Name != "acme/lib" {
+					t.Errorf("version %s has name %q", version.Version, version.Name)
+				}
+				require := "-"
+				if len(version.Require) > 0 {
+					require = "php" + version.Require["php"]
+				}
+				got = append(got, version.Version+" "+require+" "+version.Description)
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("versions:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
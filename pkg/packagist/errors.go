@@ -0,0 +1,84 @@
+package packagist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Классы сетевых ошибок. Проверяются через errors.Is:
+//
+//	if errors.Is(err, packagist.ErrNotFound) { ... }
+var (
+	// ErrNotFound - пакет или файл отсутствует в репозитории (404, 410)
+	ErrNotFound = errors.New("not found")
+	// ErrAuth - репозиторий требует авторизацию или отказал в доступе (401, 403)
+	ErrAuth = errors.New("authentication required")
+	// ErrTransient - временная ошибка (таймаут, обрыв соединения, 429, 5xx)
+	ErrTransient = errors.New("transient network error")
+	// ErrNotCached - в offline режиме файла нет в локальном кеше
+	ErrNotCached = errors.New("not available in the local cache")
+)
+
+// RequestError описывает неуспешный HTTP запрос
+type RequestError struct {
+	URL        string
+	StatusCode int   // 0, если ответ не был получен
+	Kind       error // ErrNotFound, ErrAuth, ErrTransient или nil
+	Err        error // исходная ошибка транспорта
+	// RetryAfter - задержка, запрошенная сервером (заголовок Retry-After)
+	RetryAfter time.Duration
+}
+
+// Error формирует текст ошибки
+func (e *RequestError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s returned HTTP %d", e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("request to %s failed: %v", e.URL, e.Err)
+}
+
+// Unwrap позволяет проверять как класс ошибки, так и исходную ошибку
+func (e *RequestError) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// newStatusError классифицирует ошибку по HTTP статусу
+func newStatusError(url string, status int) *RequestError {
+	return &RequestError{URL: url, StatusCode: status, Kind: classifyStatus(status)}
+}
+
+// classifyStatus определяет класс ошибки по HTTP статусу
+func classifyStatus(status int) error {
+	switch {
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return ErrNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuth
+	case isRetryableStatus(status):
+		return ErrTransient
+	}
+	return nil
+}
+
+// isRetryableStatus проверяет, имеет ли смысл повторить запрос
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusRequestTimeout,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+package packagist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/xman12/go-composer/pkg/auth"
+)
+
+const (
+	// DefaultMaxRetries - число повторов идемпотентного запроса
+	DefaultMaxRetries = 3
+	// DefaultRetryDelay - базовая задержка экспоненциального backoff
+	DefaultRetryDelay = 500 * time.Millisecond
+	// DefaultConnectTimeout - таймаут установки соединения (TCP + TLS)
+	DefaultConnectTimeout = 10 * time.Second
+	// DefaultReadTimeout - максимальная пауза между порциями данных ответа
+	DefaultReadTimeout = 60 * time.Second
+
+	// maxRetryDelay ограничивает задержку между повторами
+	maxRetryDelay = 30 * time.Second
+)
+
+// newHTTPClient создает HTTP клиент с раздельными таймаутами.
+// Общий таймаут не задается: большие архивы могут качаться долго,
+// пока данные продолжают поступать (см. idleTimeoutBody).
+func (c *Client) newHTTPClient(connectTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+
+	return &http.Client{Transport: transport, CheckRedirect: c.checkRedirect}
+}
+
+// checkRedirect не передает учетные данные на другой хост при редиректе
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.Auth != nil {
+		return c.Auth.CheckRedirect(req, via)
+	}
+
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		auth.StripCredentials(req.Header)
+	}
+	return nil
+}
+
+// SetTimeouts задает таймаут соединения и таймаут чтения (паузы между данными)
+func (c *Client) SetTimeouts(connectTimeout, readTimeout time.Duration) {
+	c.HTTPClient = c.newHTTPClient(connectTimeout)
+	c.ReadTimeout = readTimeout
+}
+
+// applyEnvironment читает настройки сети из окружения:
+// COMPOSER_HTTP_TIMEOUT (секунды) и COMPOSER_HTTP_RETRIES
+func (c *Client) applyEnvironment() {
+	if value := os.Getenv("COMPOSER_HTTP_TIMEOUT"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			c.ReadTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+	if value := os.Getenv("COMPOSER_HTTP_RETRIES"); value != "" {
+		if retries, err := strconv.Atoi(value); err == nil && retries >= 0 {
+			c.MaxRetries = retries
+		}
+	}
+}
+
+// send выполняет запрос с таймаутом чтения.
+// Временные сбои и статусы 429/5xx возвращаются как *RequestError с ErrTransient,
+// остальные ответы (включая 404 и 401) возвращаются вызывающему коду.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+
+	if err := c.Auth.Apply(req); err != nil {
+		return nil, &RequestError{URL: url, Kind: ErrAuth, Err: err}
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	body := &idleTimeoutBody{timeout: c.ReadTimeout, cancel: cancel}
+	if c.ReadTimeout > 0 {
+		body.timer = time.AfterFunc(c.ReadTimeout, body.expire)
+	}
+
+	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		body.stop()
+		if body.expired.Load() {
+			err = fmt.Errorf("no response within %s: %w", c.ReadTimeout, context.DeadlineExceeded)
+		}
+		return nil, classifyNetError(url, err)
+	}
+
+	body.ReadCloser = resp.Body
+	resp.Body = body
+
+	if isRetryableStatus(resp.StatusCode) {
+		reqErr := newStatusError(url, resp.StatusCode)
+		reqErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, reqErr
+	}
+
+	return resp, nil
+}
+
+// withRetry выполняет fn, повторяя его при временных ошибках
+// с экспоненциальной задержкой и jitter. Retry-After сервера имеет приоритет.
+func (c *Client) withRetry(fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !errors.Is(err, ErrTransient) || attempt >= c.MaxRetries {
+			return err
+		}
+
+		delay := c.backoff(attempt)
+		var reqErr *RequestError
+		if errors.As(err, &reqErr) && reqErr.RetryAfter > 0 {
+			delay = reqErr.RetryAfter
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+		}
+
+		fmt.Printf("  ⚠️  %v, retrying in %s (%d/%d)\n", err, delay.Round(time.Millisecond), attempt+1, c.MaxRetries)
+		time.Sleep(delay)
+	}
+}
+
+// backoff вычисляет задержку перед повтором: base * 2^attempt плюс случайный jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	base := c.RetryDelay
+	if base <= 0 {
+		base = DefaultRetryDelay
+	}
+
+	delay := base << uint(attempt)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP даты
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// classifyNetError оборачивает ошибку транспорта, отмечая временные сбои
+func classifyNetError(url string, err error) *RequestError {
+	reqErr := &RequestError{URL: url, Err: err}
+	if isTransientNetError(err) {
+		reqErr.Kind = ErrTransient
+	}
+	return reqErr
+}
+
+// isTransientNetError проверяет, что сетевую ошибку имеет смысл повторить
+func isTransientNetError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// idleTimeoutBody прерывает чтение ответа, если данные не поступают дольше timeout
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool
+}
+
+// Read читает данные и продлевает таймаут после каждой порции
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.timer != nil && n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF && b.expired.Load() {
+		err = fmt.Errorf("no data received for %s: %w", b.timeout, context.DeadlineExceeded)
+	}
+	return n, err
+}
+
+// Close закрывает тело ответа и освобождает таймер
+func (b *idleTimeoutBody) Close() error {
+	b.stop()
+	return b.ReadCloser.Close()
+}
+
+// expire срабатывает по таймеру и прерывает запрос
+func (b *idleTimeoutBody) expire() {
+	b.expired.Store(true)
+	b.cancel()
+}
+
+// stop останавливает таймер и освобождает контекст
+func (b *idleTimeoutBody) stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.cancel()
+}
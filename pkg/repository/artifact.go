@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// ArtifactRepository - репозиторий типа "artifact": директория с zip архивами пакетов.
+// Каждый архив должен содержать composer.json с name и version.
+type ArtifactRepository struct {
+	dir string
+
+	once     sync.Once
+	packages map[string][]packagist.PackageVersion
+	err      error
+}
+
+// NewArtifactRepository создает репозиторий для директории с архивами
+func NewArtifactRepository(dir string) *ArtifactRepository {
+	return &ArtifactRepository{dir: dir}
+}
+
+// GetPackage возвращает версии пакета из найденных архивов
+func (r *ArtifactRepository) GetPackage(name string) (*packagist.PackageInfo, error) {
+	packages, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	versions, ok := packages[name]
+	if !ok {
+		return nil, fmt.Errorf("package %s is not in artifact repository %s: %w", name, r.dir, packagist.ErrNotFound)
+	}
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{name: versions}}, nil
+}
+
+// GetDevPackage возвращает пустой список: все версии входят в GetPackage
+func (r *ArtifactRepository) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+}
+
+// load сканирует директорию один раз
+func (r *ArtifactRepository) load() (map[string][]packagist.PackageVersion, error) {
+	r.once.Do(func() {
+		r.packages, r.err = r.scan()
+	})
+	return r.packages, r.err
+}
+
+// scan читает composer.json из всех zip архивов директории (включая поддиректории)
+func (r *ArtifactRepository) scan() (map[string][]packagist.PackageVersion, error) {
+	var archives []string
+	err := filepath.WalkDir(r.dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".zip") {
+			archives = append(archives, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan artifact repository %s: %w", r.dir, err)
+	}
+	sort.Strings(archives)
+
+	packages := make(map[string][]packagist.PackageVersion)
+	for _, archive := range archives {
+		version, err := readArtifact(archive)
+		if err != nil {
+			fmt.Printf("⚠️  Skipping artifact %s: %v\n", archive, err)
+			continue
+		}
+		packages[version.Name] = append(packages[version.Name], *version)
+	}
+
+	return packages, nil
+}
+
+// readArtifact строит версию пакета по composer.json внутри архива
+func readArtifact(archive string) (*packagist.PackageVersion, error) {
+	data, err := readArchiveComposerJSON(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	var version packagist.PackageVersion
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("invalid composer.json: %w", err)
+	}
+	if version.Name == "" || version.Version == "" {
+		return nil, fmt.Errorf("composer.json must contain name and version")
+	}
+
+	shasum, err := fileSHA1(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	version.Dist = packagist.FlexibleDist{Dist: &packagist.Dist{
+		Type:   "zip",
+		URL:    filepath.ToSlash(archive),
+		Shasum: shasum,
+	}}
+	version.Source = nil
+
+	return &version, nil
+}
+
+// readArchiveComposerJSON ищет composer.json в корне архива
+// или в его единственной корневой директории
+func readArchiveComposerJSON(archive string) ([]byte, error) {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var found *zip.File
+	for _, file := range reader.File {
+		depth := strings.Count(strings.Trim(file.Name, "/"), "/")
+		if filepath.Base(file.Name) != "composer.json" || depth > 1 {
+			continue
+		}
+		if found == nil || depth < strings.Count(found.Name, "/") {
+			found = file
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("composer.json not found in archive")
+	}
+
+	rc, err := found.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// fileSHA1 вычисляет sha1 файла
+func fileSHA1(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha1.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// ComposerRepository - репозиторий типа "composer" (Packagist, приватный Packagist, Satis).
+// Формат репозитория определяется по packages.json: metadata-url (Composer 2),
+// providers-url (Composer 1) или пакеты, перечисленные прямо в packages.json.
+type ComposerRepository struct {
+	url    string
+	client *packagist.Client
+
+	once  sync.Once
+	index *packagesIndex
+	err   error
+
+	providersOnce sync.Once
+	providersErr  error
+}
+
+// packagesIndex - содержимое packages.json
+type packagesIndex struct {
+	MetadataURL              string              `json:"metadata-url"`
+	AvailablePackages        []string            `json:"available-packages"`
+	AvailablePackagePatterns []string            `json:"available-package-patterns"`
+	ProvidersURL             string              `json:"providers-url"`
+	Providers                map[string]fileHash `json:"providers"`
+	ProviderIncludes         map[string]fileHash `json:"provider-includes"`
+	Includes                 map[string]fileHash `json:"includes"`
+
+	// packages - пакеты из packages.json и файлов includes
+	packages *packagist.PackageInfo
+	// patterns - скомпилированные available-package-patterns
+	patterns []*regexp.Regexp
+}
+
+// fileHash - хеш файла в providers/includes
+type fileHash struct {
+	SHA256 string `json:"sha256"`
+	SHA1   string `json:"sha1"`
+}
+
+// NewComposerRepository создает репозиторий по его URL
+func NewComposerRepository(repoURL string, client *packagist.Client) *ComposerRepository {
+	return &ComposerRepository{
+		url:    strings.TrimRight(repoURL, "/"),
+		client: client,
+	}
+}
+
+// NewPackagist создает репозиторий packagist.org.
+// Формат packagist.org известен, поэтому packages.json не запрашивается.
+func NewPackagist(client *packagist.Client) *ComposerRepository {
+	repo := NewComposerRepository(client.BaseURL, client)
+	repo.once.Do(func() {
+		repo.index = &packagesIndex{
+			MetadataURL: repo.url + "/p2/%package%.json",
+			packages:    &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}},
+		}
+	})
+	return repo
+}
+
+// GetPackage возвращает версии пакета из packages.json и metadata-url (или providers-url)
+func (r *ComposerRepository) GetPackage(name string) (*packagist.PackageInfo, error) {
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}
+	if !index.available(name) {
+		return nil, r.notFound(name)
+	}
+
+	if versions := index.packages.Packages[name]; len(versions) > 0 {
+		info.Packages[name] = append(info.Packages[name], versions...)
+	}
+
+	var remote *packagist.PackageInfo
+	switch {
+	case index.MetadataURL != "":
+		remote, err = r.fetchPackages(index.MetadataURL, name, "", name)
+	case index.ProvidersURL != "":
+		remote, err = r.fetchProvider(index, name)
+	case len(info.Packages[name]) == 0:
+		err = r.notFound(name)
+	}
+	if err != nil {
+		// Пакет, описанный прямо в packages.json, может отсутствовать по metadata-url
+		if errors.Is(err, packagist.ErrNotFound) && len(info.Packages[name]) > 0 {
+			return info, nil
+		}
+		return nil, err
+	}
+	if remote != nil {
+		info.Packages[name] = append(info.Packages[name], remote.Packages[name]...)
+		info.Minified = remote.Minified
+	}
+
+	return info, nil
+}
+
+// GetDevPackage возвращает версии-ветки из файла <name>~dev (только metadata-url).
+// В остальных форматах ветки входят в GetPackage.
+func (r *ComposerRepository) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	index, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if index.MetadataURL == "" || !index.available(name) {
+		return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+	}
+
+	info, err := r.fetchPackages(index.MetadataURL, name+"~dev", "", name+"~dev")
+	if err != nil {
+		// Пакет без веток: файла ~dev нет или он не попал в offline кеш
+		if errors.Is(err, packagist.ErrNotFound) || errors.Is(err, packagist.ErrNotCached) {
+			return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+// load загружает packages.json один раз
+func (r *ComposerRepository) load() (*packagesIndex, error) {
+	r.once.Do(func() {
+		r.index, r.err = r.loadIndex()
+	})
+	return r.index, r.err
+}
+
+// loadIndex разбирает packages.json и подключает файлы includes
+func (r *ComposerRepository) loadIndex() (*packagesIndex, error) {
+	body, err := r.client.Fetch(r.packagesURL(), r.url, "packages.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repository %s: %w", r.url, err)
+	}
+
+	index := &packagesIndex{}
+	if err := json.Unmarshal(body, index); err != nil {
+		return nil, fmt.Errorf("invalid packages.json in repository %s: %w", r.url, err)
+	}
+
+	index.packages, err = packagist.ParsePackages(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid packages.json in repository %s: %w", r.url, err)
+	}
+
+	index.patterns = namePatterns(index.AvailablePackagePatterns)
+
+	// Satis складывает пакеты в отдельные файлы includes
+	for _, path := range sortedKeys(index.Includes) {
+		body, err := r.client.Fetch(r.resolve(path), r.url, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s from repository %s: %w", path, r.url, err)
+		}
+
+		included, err := packagist.ParsePackages(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in repository %s: %w", path, r.url, err)
+		}
+		for name, versions := range included.Packages {
+			index.packages.Packages[name] = append(index.packages.Packages[name], versions...)
+		}
+	}
+
+	return index, nil
+}
+
+// fetchPackages загружает файл метаданных по шаблону с %package% и %hash%.
+// Отсутствующий файл (404) возвращается как ошибка packagist.ErrNotFound.
+func (r *ComposerRepository) fetchPackages(template, name, hash, key string) (*packagist.PackageInfo, error) {
+	path := strings.ReplaceAll(template, "%package%", name)
+	path = strings.ReplaceAll(path, "%hash%", hash)
+
+	body, err := r.client.Fetch(r.resolve(path), r.url, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch package %s: %w", strings.TrimSuffix(name, "~dev"), err)
+	}
+
+	return packagist.ParsePackages(body)
+}
+
+// fetchProvider загружает пакет через providers-url (формат Composer 1)
+func (r *ComposerRepository) fetchProvider(index *packagesIndex, name string) (*packagist.PackageInfo, error) {
+	r.providersOnce.Do(func() {
+		r.providersErr = r.loadProviders(index)
+	})
+	if r.providersErr != nil {
+		return nil, r.providersErr
+	}
+
+	hash, ok := index.Providers[name]
+	if !ok && len(index.Providers) > 0 {
+		return nil, r.notFound(name)
+	}
+
+	return r.fetchPackages(index.ProvidersURL, name, hash.SHA256, name)
+}
+
+// loadProviders объединяет списки провайдеров из provider-includes
+func (r *ComposerRepository) loadProviders(index *packagesIndex) error {
+	if index.Providers == nil {
+		index.Providers = make(map[string]fileHash)
+	}
+
+	for _, template := range sortedKeys(index.ProviderIncludes) {
+		path := strings.ReplaceAll(template, "%hash%", index.ProviderIncludes[template].SHA256)
+
+		body, err := r.client.Fetch(r.resolve(path), r.url, template)
+		if err != nil {
+			return fmt.Errorf("failed to load %s from repository %s: %w", path, r.url, err)
+		}
+
+		var include struct {
+			Providers map[string]fileHash `json:"providers"`
+		}
+		if err := json.Unmarshal(body, &include); err != nil {
+			return fmt.Errorf("invalid %s in repository %s: %w", path, r.url, err)
+		}
+		for name, hash := range include.Providers {
+			index.Providers[name] = hash
+		}
+	}
+
+	return nil
+}
+
+// notFound возвращает ошибку об отсутствии пакета в репозитории
+func (r *ComposerRepository) notFound(name string) error {
+	return fmt.Errorf("package %s is not in repository %s: %w", name, r.url, packagist.ErrNotFound)
+}
+
+// available проверяет available-packages и available-package-patterns.
+// Если оба списка пусты, репозиторий может содержать любой пакет.
+func (i *packagesIndex) available(name string) bool {
+	if len(i.AvailablePackages) == 0 && len(i.patterns) == 0 {
+		return true
+	}
+
+	for _, available := range i.AvailablePackages {
+		if available == name {
+			return true
+		}
+	}
+	return matchAny(i.patterns, name)
+}
+
+// packagesURL возвращает адрес packages.json репозитория
+func (r *ComposerRepository) packagesURL() string {
+	if strings.HasSuffix(r.url, ".json") {
+		return r.url
+	}
+	return r.url + "/packages.json"
+}
+
+// resolve строит абсолютный URL из пути относительно packages.json
+func (r *ComposerRepository) resolve(path string) string {
+	base, err := url.Parse(r.packagesURL())
+	if err != nil {
+		return path
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// sortedKeys возвращает ключи в алфавитном порядке
+func sortedKeys(values map[string]fileHash) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// PackageRepository - репозиторий типа "package": пакеты, описанные прямо
+// в composer.json (для библиотек без собственного composer.json)
+type PackageRepository struct {
+	packages map[string][]packagist.PackageVersion
+}
+
+// NewPackageRepository разбирает секцию package: один объект или массив версий
+func NewPackageRepository(data json.RawMessage) (*PackageRepository, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("package repository has no package definition")
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		list = []json.RawMessage{data}
+	}
+
+	repo := &PackageRepository{packages: make(map[string][]packagist.PackageVersion)}
+	for _, item := range list {
+		var version packagist.PackageVersion
+		if err := json.Unmarshal(item, &version); err != nil {
+			return nil, fmt.Errorf("invalid package repository: %w", err)
+		}
+		if version.Name == "" || version.Version == "" {
+			return nil, fmt.Errorf("package repository entries must contain name and version")
+		}
+		if version.Dist.Dist == nil && version.Source == nil {
+			return nil, fmt.Errorf("package %s %s in package repository has neither dist nor source", version.Name, version.Version)
+		}
+		repo.packages[version.Name] = append(repo.packages[version.Name], version)
+	}
+
+	return repo, nil
+}
+
+// GetPackage возвращает описанные версии пакета
+func (r *PackageRepository) GetPackage(name string) (*packagist.PackageInfo, error) {
+	versions, ok := r.packages[name]
+	if !ok {
+		return nil, fmt.Errorf("package %s is not in package repository: %w", name, packagist.ErrNotFound)
+	}
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{name: versions}}, nil
+}
+
+// GetDevPackage возвращает пустой список: все версии входят в GetPackage
+func (r *PackageRepository) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// DefaultPathVersion - версия пакета path-репозитория без version и без git
+const DefaultPathVersion = "dev-main"
+
+// PathRepository - репозиторий типа "path": пакеты из локальных директорий.
+// URL может содержать glob ("packages/*"); каждая найденная директория
+// с composer.json становится пакетом с dist.type "path".
+type PathRepository struct {
+	url     string
+	options map[string]interface{}
+
+	once     sync.Once
+	packages map[string][]packagist.PackageVersion
+	err      error
+}
+
+// NewPathRepository создает path-репозиторий; options - секция options из composer.json
+func NewPathRepository(url string, options map[string]interface{}) *PathRepository {
+	return &PathRepository{url: url, options: options}
+}
+
+// GetPackage возвращает пакет из локальной директории
+func (r *PathRepository) GetPackage(name string) (*packagist.PackageInfo, error) {
+	packages, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	versions, ok := packages[name]
+	if !ok {
+		return nil, fmt.Errorf("package %s is not in path repository %s: %w", name, r.url, packagist.ErrNotFound)
+	}
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{name: versions}}, nil
+}
+
+// GetDevPackage возвращает пустой список: версии-ветки входят в GetPackage
+func (r *PathRepository) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+}
+
+// load находит пакеты по glob один раз
+func (r *PathRepository) load() (map[string][]packagist.PackageVersion, error) {
+	r.once.Do(func() {
+		r.packages, r.err = r.scan()
+	})
+	return r.packages, r.err
+}
+
+// scan читает composer.json из всех директорий, подходящих под url
+func (r *PathRepository) scan() (map[string][]packagist.PackageVersion, error) {
+	matches, err := filepath.Glob(filepath.FromSlash(r.url))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path repository url %s: %w", r.url, err)
+	}
+	sort.Strings(matches)
+
+	packages := make(map[string][]packagist.PackageVersion)
+	for _, dir := range matches {
+		data, err := os.ReadFile(filepath.Join(dir, "composer.json"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		version, err := r.readPackage(dir, data)
+		if err != nil {
+			return nil, err
+		}
+		packages[version.Name] = append(packages[version.Name], *version)
+	}
+
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("path repository %s does not contain any package with composer.json", r.url)
+	}
+
+	return packages, nil
+}
+
+// readPackage строит версию пакета из его composer.json
+func (r *PathRepository) readPackage(dir string, data []byte) (*packagist.PackageVersion, error) {
+	var version packagist.PackageVersion
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Join(dir, "composer.json"), err)
+	}
+	if version.Name == "" {
+		return nil, fmt.Errorf("package in %s has no name", dir)
+	}
+
+	if version.Version == "" {
+		version.Version = guessVersion(dir)
+	}
+
+	// reference меняется вместе с composer.json и options, как в Composer
+	options, _ := json.Marshal(r.options)
+	sum := sha1.Sum(append(data, options...))
+
+	version.Dist = packagist.FlexibleDist{Dist: &packagist.Dist{
+		Type:      "path",
+		URL:       filepath.ToSlash(dir),
+		Reference: hex.EncodeToString(sum[:]),
+	}}
+	version.Source = nil
+	version.TransportOptions = r.options
+
+	return &version, nil
+}
+
+// guessVersion определяет версию по текущей git ветке ("dev-<branch>"),
+// иначе возвращает DefaultPathVersion
+func guessVersion(dir string) string {
+	if branch := gitBranch(dir); branch != "" {
+		return "dev-" + branch
+	}
+	return DefaultPathVersion
+}
+
+// gitBranch возвращает текущую ветку git репозитория, в котором находится dir
+func gitBranch(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		gitDir := filepath.Join(abs, ".git")
+		if info, err := os.Stat(gitDir); err == nil {
+			// Worktree и submodule: .git - файл со ссылкой "gitdir: <path>"
+			if !info.IsDir() {
+				data, err := os.ReadFile(gitDir)
+				if err != nil {
+					return ""
+				}
+				gitDir = strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(abs, gitDir)
+				}
+			}
+
+			head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			ref := strings.TrimSpace(string(head))
+			if !strings.HasPrefix(ref, "ref: refs/heads/") {
+				return "" // detached HEAD
+			}
+			return strings.TrimPrefix(ref, "ref: refs/heads/")
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return ""
+		}
+		abs = parent
+	}
+}
@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/xman12/go-composer/pkg/composer"
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// Repository - источник метаданных пакетов.
+// Пакет, которого нет в репозитории, возвращается с пустым списком версий
+// или с ошибкой packagist.ErrNotFound.
+type Repository interface {
+	// GetPackage возвращает теги и стабильные версии пакета
+	GetPackage(name string) (*packagist.PackageInfo, error)
+	// GetDevPackage возвращает версии-ветки пакета
+	GetDevPackage(name string) (*packagist.PackageInfo, error)
+}
+
+// Manager опрашивает репозитории в порядке приоритета.
+// По умолчанию репозитории канонические: пакет берется целиком из первого
+// репозитория, в котором он найден, поэтому одноименный пакет на packagist.org
+// не может подменить внутренний (dependency confusion). Неканонический репозиторий
+// добавляет свои версии к версиям следующих репозиториев.
+type Manager struct {
+	repos []entry
+
+	mu      sync.Mutex
+	lookups map[string]*lookupCall
+}
+
+// Filter - правила выбора пакетов репозиторием
+type Filter struct {
+	// Canonical останавливает поиск пакета в следующих репозиториях
+	Canonical bool
+	// Only - шаблоны имен ("acme/*"), которые может отдавать репозиторий
+	Only []string
+	// Exclude - шаблоны имен, которые репозиторий не отдает
+	Exclude []string
+}
+
+// entry - репозиторий вместе с правилами фильтрации
+type entry struct {
+	repo      Repository
+	canonical bool
+	only      []*regexp.Regexp
+	exclude   []*regexp.Regexp
+}
+
+// lookupCall - результат поиска пакета по репозиториям
+type lookupCall struct {
+	once    sync.Once
+	sources []source
+	stable  *packagist.PackageInfo
+	err     error
+}
+
+// source - репозиторий, в котором найден пакет; dev загружены заранее, если не nil
+type source struct {
+	repo Repository
+	dev  *packagist.PackageInfo
+}
+
+// NewManager создает менеджер из канонических репозиториев без фильтров
+func NewManager(repos ...Repository) *Manager {
+	m := &Manager{lookups: make(map[string]*lookupCall)}
+	for _, repo := range repos {
+		m.Add(repo, Filter{Canonical: true})
+	}
+	return m
+}
+
+// Add добавляет репозиторий с наименьшим приоритетом
+func (m *Manager) Add(repo Repository, filter Filter) {
+	m.repos = append(m.repos, entry{
+		repo:      repo,
+		canonical: filter.Canonical,
+		only:      namePatterns(filter.Only),
+		exclude:   namePatterns(filter.Exclude),
+	})
+}
+
+// New создает менеджер по секции repositories из composer.json.
+// Репозитории опрашиваются в порядке объявления, packagist.org добавляется
+// последним, если он не отключен записью {"packagist.org": false} или {"packagist": false}
+// и не объявлен явно.
+func New(configs composer.Repositories, client *packagist.Client) (*Manager, error) {
+	m := NewManager()
+	packagistDeclared := false
+
+	for _, config := range configs {
+		if config.Disabled {
+			continue
+		}
+
+		var repo Repository
+		switch config.Type {
+		case "composer":
+			if config.URL == "" {
+				return nil, fmt.Errorf("composer repository %s has no url", config.Name)
+			}
+			if isPackagistURL(config.URL) {
+				repo, packagistDeclared = NewPackagist(client), true
+			} else {
+				repo = NewComposerRepository(config.URL, client)
+			}
+		case "path":
+			if config.URL == "" {
+				return nil, fmt.Errorf("path repository %s has no url", config.Name)
+			}
+			repo = NewPathRepository(config.URL, config.Options)
+		case "vcs", "git", "github", "gitlab", "bitbucket":
+			if config.URL == "" {
+				return nil, fmt.Errorf("vcs repository %s has no url", config.Name)
+			}
+			repo = NewVcsRepository(config.URL, client)
+		case "package":
+			pkgRepo, err := NewPackageRepository(config.Package)
+			if err != nil {
+				return nil, err
+			}
+			repo = pkgRepo
+		case "artifact":
+			if config.URL == "" {
+				return nil, fmt.Errorf("artifact repository %s has no url", config.Name)
+			}
+			repo = NewArtifactRepository(config.URL)
+		default:
+			fmt.Printf("⚠️  Repository type %q is not supported yet, skipping %s\n", config.Type, config.URL)
+			continue
+		}
+
+		if len(config.Only) > 0 && len(config.Exclude) > 0 {
+			return nil, fmt.Errorf("repository %s cannot have both only and exclude", config.URL)
+		}
+		m.Add(repo, Filter{
+			Canonical: config.IsCanonical(),
+			Only:      config.Only,
+			Exclude:   config.Exclude,
+		})
+	}
+
+	if !configs.PackagistDisabled() && !packagistDeclared {
+		m.Add(NewPackagist(client), Filter{Canonical: true})
+	}
+
+	return m, nil
+}
+
+// GetPackage возвращает стабильные версии пакета из репозиториев, где он найден
+func (m *Manager) GetPackage(name string) (*packagist.PackageInfo, error) {
+	call, err := m.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return call.stable, nil
+}
+
+// GetDevPackage возвращает версии-ветки пакета из тех же репозиториев, что и GetPackage
+func (m *Manager) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	call, err := m.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := emptyInfo(nil)
+	for _, src := range call.sources {
+		dev := src.dev
+		if dev == nil {
+			if dev, err = src.repo.GetDevPackage(name); err != nil {
+				return nil, err
+			}
+		}
+		mergeVersions(merged, dev, name)
+	}
+	return merged, nil
+}
+
+// lookup ищет пакет один раз, параллельные вызовы ждут результат
+func (m *Manager) lookup(name string) (*lookupCall, error) {
+	m.mu.Lock()
+	call, ok := m.lookups[name]
+	if !ok {
+		call = &lookupCall{}
+		m.lookups[name] = call
+	}
+	m.mu.Unlock()
+
+	call.once.Do(func() { m.find(name, call) })
+	return call, call.err
+}
+
+// find опрашивает репозитории по порядку, пропуская запрещенные only/exclude,
+// и останавливается на первом каноническом репозитории с пакетом.
+// Пакет без тегов считается найденным, если в репозитории есть его ветки.
+func (m *Manager) find(name string, call *lookupCall) {
+	var lastErr error
+	call.stable = emptyInfo(nil)
+
+	for i, entry := range m.repos {
+		if !entry.allows(name) {
+			continue
+		}
+
+		stable, err := entry.repo.GetPackage(name)
+		if err != nil {
+			if entry.skips(err) {
+				lastErr = err
+				continue
+			}
+			call.err = err
+			return
+		}
+
+		src := source{repo: entry.repo}
+		found := hasVersions(stable, name)
+		if !found {
+			if i == len(m.repos)-1 {
+				// Ветки последнего репозитория загрузит resolver, если они понадобятся
+				found = stable != nil
+			} else {
+				dev, err := entry.repo.GetDevPackage(name)
+				if err != nil && !entry.skips(err) {
+					call.err = err
+					return
+				}
+				if err == nil && hasVersions(dev, name) {
+					src.dev, found = dev, true
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+
+		call.sources = append(call.sources, src)
+		mergeVersions(call.stable, stable, name)
+		if entry.canonical {
+			return
+		}
+	}
+
+	if len(call.sources) > 0 {
+		return
+	}
+	if lastErr != nil && len(m.repos) == 1 {
+		call.err = lastErr
+		return
+	}
+	if lastErr != nil && errors.Is(lastErr, packagist.ErrNotCached) {
+		call.err = fmt.Errorf("package %s was not found in any repository: %w", name, lastErr)
+		return
+	}
+	call.err = fmt.Errorf("package %s was not found in any repository: %w", name, packagist.ErrNotFound)
+}
+
+// allows проверяет правила only/exclude для имени пакета
+func (e entry) allows(name string) bool {
+	if len(e.only) > 0 && !matchAny(e.only, name) {
+		return false
+	}
+	return !matchAny(e.exclude, name)
+}
+
+// namePatterns компилирует шаблоны имен пакетов с * в регулярные выражения
+func namePatterns(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+		compiled = append(compiled, regexp.MustCompile(expr))
+	}
+	return compiled
+}
+
+// matchAny проверяет, подходит ли имя под один из шаблонов
+func matchAny(patterns []*regexp.Regexp, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPackagistURL проверяет, что url указывает на packagist.org
+func isPackagistURL(url string) bool {
+	url = strings.TrimRight(strings.ToLower(url), "/")
+	return url == "https://repo.packagist.org" || url == "https://packagist.org"
+}
+
+// mergeVersions добавляет версии пакета из info в merged.
+// Версия, уже полученная из более приоритетного репозитория, не заменяется.
+func mergeVersions(merged, info *packagist.PackageInfo, name string) {
+	if info == nil {
+		return
+	}
+
+	seen := make(map[string]bool, len(merged.Packages[name]))
+	for _, version := range merged.Packages[name] {
+		seen[version.Version] = true
+	}
+	for _, version := range info.Packages[name] {
+		if !seen[version.Version] {
+			merged.Packages[name] = append(merged.Packages[name], version)
+		}
+	}
+
+	if info.Minified != "" {
+		merged.Minified = info.Minified
+	}
+}
+
+// skips проверяет, что после ошибки репозитория поиск можно продолжить в следующих.
+// Пакета нет (ErrNotFound) - продолжаем всегда. Метаданных нет в offline кеше
+// (ErrNotCached) - только для неканонического репозитория: иначе пакет из
+// приватного репозитория был бы подменен одноименным пакетом с packagist.org.
+func (e entry) skips(err error) bool {
+	if errors.Is(err, packagist.ErrNotFound) {
+		return true
+	}
+	return !e.canonical && errors.Is(err, packagist.ErrNotCached)
+}
+
+// hasVersions проверяет, что в метаданных есть версии пакета
+func hasVersions(info *packagist.PackageInfo, name string) bool {
+	return info != nil && len(info.Packages[name]) > 0
+}
+
+// emptyInfo возвращает info или пустые метаданные вместо nil
+func emptyInfo(info *packagist.PackageInfo) *packagist.PackageInfo {
+	if info != nil {
+		return info
+	}
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}
+}
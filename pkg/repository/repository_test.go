@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// fakeRepo отдает одну версию пакета или ошибку err
+type fakeRepo struct {
+	version string
+	err     error
+}
+
+func (r fakeRepo) GetPackage(name string) (*packagist.PackageInfo, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{
+		name: {{Name: name, Version: r.version}},
+	}}, nil
+}
+
+func (r fakeRepo) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+}
+
+func TestManagerFallthrough(t *testing.T) {
+	public := fakeRepo{version: "9.9.9"}
+
+	tests := []struct {
+		name      string
+		first     fakeRepo
+		canonical bool
+		want      string
+		wantErr   error
+	}{
+		{"canonical repo has the package", fakeRepo{version: "1.0.0"}, true, "1.0.0", nil},
+		{"canonical repo without the package", fakeRepo{err: packagist.ErrNotFound}, true, "9.9.9", nil},
+		{"canonical repo not cached offline", fakeRepo{err: packagist.ErrNotCached}, true, "", packagist.ErrNotCached},
+		{"non-canonical repo not cached offline", fakeRepo{err: packagist.ErrNotCached}, false, "9.9.9", nil},
+		{"canonical repo auth error", fakeRepo{err: packagist.ErrAuth}, true, "", packagist.ErrAuth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			m.Add(tt.first, Filter{Canonical: tt.canonical})
+			m.Add(public, Filter{Canonical: true})
+
+			info, err := m.GetPackage("acme/private")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			versions := info.Packages["acme/private"]
+			if len(versions) != 1 || versions[0].Version != tt.want {
+				t.Errorf("versions = %+v, want only %s", versions, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagerFilters(t *testing.T) {
+	private := fakeRepo{version: "1.0.0"}
+	public := fakeRepo{version: "9.9.9"}
+
+	tests := []struct {
+		name       string
+		filter     Filter
+		lookup     string
+		want       string // пусто - пакет не найден ни в одном репозитории
+		withPublic bool   // добавлять ли публичный репозиторий вторым
+	}{
+		{"only allows listed package", Filter{Canonical: true, Only: []string{"acme/private"}}, "acme/private", "1.0.0", true},
+		{"only skips other packages", Filter{Canonical: true, Only: []string{"acme/private"}}, "acme/other", "9.9.9", true},
+		{"only with wildcard", Filter{Canonical: true, Only: []string{"acme/*"}}, "acme/anything", "1.0.0", true},
+		{"only wildcard does not match other vendor", Filter{Canonical: true, Only: []string{"acme/*"}}, "acmecorp/lib", "9.9.9", true},
+		{"only is case-insensitive", Filter{Canonical: true, Only: []string{"Acme/*"}}, "acme/lib", "1.0.0", true},
+		{"exclude skips listed package", Filter{Canonical: true, Exclude: []string{"acme/public"}}, "acme/public", "9.9.9", true},
+		{"exclude keeps other packages", Filter{Canonical: true, Exclude: []string{"acme/public"}}, "acme/private", "1.0.0", true},
+		{"exclude with wildcard", Filter{Canonical: true, Exclude: []string{"*/polyfill-*"}}, "symfony/polyfill-php80", "9.9.9", true},
+		{"exclude is case-insensitive", Filter{Canonical: true, Exclude: []string{"ACME/PUBLIC"}}, "acme/public", "9.9.9", true},
+		{"exclude wins over only", Filter{Canonical: true, Only: []string{"acme/*"}, Exclude: []string{"acme/public"}}, "acme/public", "9.9.9", true},
+		{"pattern metacharacters are literal", Filter{Canonical: true, Only: []string{"acme/lib.x"}}, "acme/libyx", "9.9.9", true},
+		{"excluded without a later repository", Filter{Canonical: true, Exclude: []string{"acme/*"}}, "acme/lib", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			m.Add(private, tt.filter)
+			if tt.withPublic {
+				m.Add(public, Filter{Canonical: true})
+			}
+
+			info, err := m.GetPackage(tt.lookup)
+			if tt.want == "" {
+				if !errors.Is(err, packagist.ErrNotFound) {
+					t.Fatalf("err = %v, want %v", err, packagist.ErrNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			versions := info.Packages[tt.lookup]
+			if len(versions) != 1 || versions[0].Version != tt.want {
+				t.Errorf("versions = %+v, want only %s", versions, tt.want)
+			}
+		})
+	}
+}
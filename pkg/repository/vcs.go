@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/xman12/go-composer/pkg/packagist"
+	"github.com/xman12/go-composer/pkg/vcs"
+)
+
+// numericBranch - ветки вида 1.x, 2.0, v3.x (до четырех частей) получают версию "<X.Y.x>-dev"
+var numericBranch = regexp.MustCompile(`^v?\d+(\.(\d+|[xX*])){0,3}$`)
+
+// VcsRepository - репозиторий типа "vcs"/"git": версии пакета читаются
+// из тегов и веток git репозитория, composer.json берется из каждого ref.
+type VcsRepository struct {
+	git *vcs.Git
+
+	once     sync.Once
+	name     string
+	versions []packagist.PackageVersion
+	err      error
+}
+
+// NewVcsRepository создает репозиторий для git url (удаленного, file:// или локального пути)
+func NewVcsRepository(url string, client *packagist.Client) *VcsRepository {
+	return &VcsRepository{git: vcs.NewGit(url, client.Cache, client.Offline)}
+}
+
+// GetPackage возвращает версии пакета из тегов и веток
+func (r *VcsRepository) GetPackage(name string) (*packagist.PackageInfo, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	if name != r.name {
+		return nil, fmt.Errorf("package %s is not in repository %s: %w", name, r.git.URL(), packagist.ErrNotFound)
+	}
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{name: r.versions}}, nil
+}
+
+// GetDevPackage возвращает пустой список: ветки входят в GetPackage
+func (r *VcsRepository) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+}
+
+// load читает репозиторий один раз
+func (r *VcsRepository) load() error {
+	r.once.Do(func() {
+		r.err = r.scan()
+	})
+	return r.err
+}
+
+// scan определяет имя пакета по ветке по умолчанию и собирает версии всех refs.
+// Refs без composer.json или с другим именем пакета пропускаются.
+func (r *VcsRepository) scan() error {
+	branch, err := r.git.DefaultBranch()
+	if err != nil {
+		return err
+	}
+
+	refs, err := r.git.Refs()
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if !ref.IsTag && ref.Name == branch {
+			root, err := r.readVersion(ref)
+			if err != nil {
+				return fmt.Errorf("cannot read composer.json of %s (branch %s): %w", r.git.URL(), branch, err)
+			}
+			r.name = root.Name
+		}
+	}
+	if r.name == "" {
+		return fmt.Errorf("repository %s has no composer.json with a package name", r.git.URL())
+	}
+
+	for _, ref := range refs {
+		version, err := r.readVersion(ref)
+		if err != nil || version.Name != r.name {
+			continue
+		}
+		r.versions = append(r.versions, *version)
+	}
+
+	return nil
+}
+
+// readVersion строит версию пакета из composer.json в ref
+func (r *VcsRepository) readVersion(ref vcs.Ref) (*packagist.PackageVersion, error) {
+	versionName, ok := refVersion(ref)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a version", ref.Name)
+	}
+
+	data, err := r.git.ReadFile(ref.Commit, "composer.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var version packagist.PackageVersion
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, err
+	}
+
+	version.Version = versionName
+	version.Source = &packagist.Source{
+		Type:      "git",
+		URL:       r.git.URL(),
+		Reference: ref.Commit,
+	}
+	version.Dist = packagist.FlexibleDist{}
+	if version.Time == "" {
+		version.Time, _ = r.git.CommitTime(ref.Commit)
+	}
+
+	return &version, nil
+}
+
+// refVersion преобразует тег или ветку в версию Composer:
+// теги должны быть валидными версиями, ветки становятся "dev-<ветка>" или "<ветка>-dev"
+func refVersion(ref vcs.Ref) (string, bool) {
+	if ref.IsTag {
+		if _, err := semver.NewVersion(strings.TrimPrefix(ref.Name, "v")); err != nil {
+			return "", false
+		}
+		return ref.Name, true
+	}
+
+	if numericBranch.MatchString(ref.Name) {
+		return numericBranchVersion(ref.Name), true
+	}
+	return "dev-" + ref.Name, true
+}
+
+// numericBranchVersion нормализует числовую ветку как Composer: "v" убирается,
+// "*" и "X" становятся "x", недостающие части дополняются "x", а хвост из "x"
+// сворачивается в один ("2.0" -> "2.0.x-dev", "2" -> "2.x-dev", "1.x" -> "1.x-dev")
+func numericBranchVersion(branch string) string {
+	parts := strings.Split(strings.TrimPrefix(branch, "v"), ".")
+	for i, part := range parts {
+		if part == "*" || part == "X" {
+			parts[i] = "x"
+		}
+	}
+
+	for len(parts) > 0 && parts[len(parts)-1] == "x" {
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) < 4 {
+		parts = append(parts, "x")
+	}
+	return strings.Join(parts, ".") + "-dev"
+}
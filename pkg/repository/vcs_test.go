@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/xman12/go-composer/pkg/vcs"
+)
+
+func TestRefVersion(t *testing.T) {
+	tests := []struct {
+		ref  vcs.Ref
+		want string
+		ok   bool
+	}{
+		{vcs.Ref{Name: "v1.2.0", IsTag: true}, "v1.2.0", true},
+		{vcs.Ref{Name: "1.2", IsTag: true}, "1.2", true},
+		{vcs.Ref{Name: "release-candidate", IsTag: true}, "", false},
+		{vcs.Ref{Name: "main"}, "dev-main", true},
+		{vcs.Ref{Name: "feature/login"}, "dev-feature/login", true},
+		{vcs.Ref{Name: "2"}, "2.x-dev", true},
+		{vcs.Ref{Name: "2.0"}, "2.0.x-dev", true},
+		{vcs.Ref{Name: "v2.0"}, "2.0.x-dev", true},
+		{vcs.Ref{Name: "1.x"}, "1.x-dev", true},
+		{vcs.Ref{Name: "1.X"}, "1.x-dev", true},
+		{vcs.Ref{Name: "1.2.*"}, "1.2.x-dev", true},
+		{vcs.Ref{Name: "1.2.3"}, "1.2.3.x-dev", true},
+		{vcs.Ref{Name: "1.2.3.4"}, "1.2.3.4-dev", true},
+		{vcs.Ref{Name: "1.2.3.4.5"}, "dev-1.2.3.4.5", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref.Name, func(t *testing.T) {
+			got, ok := refVersion(tt.ref)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("refVersion(%+v) = %q, %v; want %q, %v", tt.ref, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package resolver
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// DefaultConcurrency - число параллельных запросов метаданных по умолчанию
+const DefaultConcurrency = 12
+
+// metadataStore кеширует метаданные пакетов в памяти.
+// Каждый пакет запрашивается не более одного раза: параллельные запросы
+// одного и того же имени ждут результат первого (singleflight).
+type metadataStore struct {
+	repo Repository
+
+	mu    sync.Mutex
+	calls map[string]*metadataCall
+	sem   chan struct{}
+}
+
+// metadataCall - результат (или ожидание результата) загрузки метаданных
+type metadataCall struct {
+	done chan struct{}
+	info *packagist.PackageInfo
+	err  error
+}
+
+// newMetadataStore создает хранилище с ограничением параллельных запросов
+func newMetadataStore(repo Repository, concurrency int) *metadataStore {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &metadataStore{
+		repo:  repo,
+		calls: make(map[string]*metadataCall),
+		sem:   make(chan struct{}, concurrency),
+	}
+}
+
+// get возвращает метаданные пакета; withDev добавляет версии-ветки из ~dev
+func (s *metadataStore) get(name string, withDev bool) (*packagist.PackageInfo, error) {
+	info, err := s.load(name, func() (*packagist.PackageInfo, error) {
+		return s.repo.GetPackage(name)
+	})
+	if err != nil || !withDev {
+		return info, err
+	}
+
+	dev, err := s.load(name+"~dev", func() (*packagist.PackageInfo, error) {
+		return s.repo.GetDevPackage(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mergePackageInfo(info, dev), nil
+}
+
+// load выполняет fetch один раз для ключа, остальные вызовы ждут результат
+func (s *metadataStore) load(key string, fetch func() (*packagist.PackageInfo, error)) (*packagist.PackageInfo, error) {
+	s.mu.Lock()
+	if call, ok := s.calls[key]; ok {
+		s.mu.Unlock()
+		<-call.done
+		return call.info, call.err
+	}
+	call := &metadataCall{done: make(chan struct{})}
+	s.calls[key] = call
+	s.mu.Unlock()
+
+	s.sem <- struct{}{}
+	call.info, call.err = fetch()
+	<-s.sem
+	close(call.done)
+
+	return call.info, call.err
+}
+
+// prefetch запускает фоновую загрузку метаданных пакетов, которые resolver
+// поставил в очередь: пока разрешается первый из них, остальные уже загружаются.
+// Зависимости заранее не угадываются - загружается только то, что понадобится
+// resolver'у, а число одновременных запросов ограничено тем же семафором (--jobs).
+func (s *metadataStore) prefetch(names []string) {
+	for _, name := range names {
+		s.mu.Lock()
+		_, started := s.calls[name]
+		s.mu.Unlock()
+		if started {
+			continue
+		}
+
+		// Ошибка сохраняется и будет возвращена при реальном запросе
+		go s.get(name, false)
+	}
+}
+
+// dependencyNames возвращает отсортированный список реальных пакетов из require,
+// исключая платформенные и замененные через replace
+func dependencyNames(require map[string]string, replaced map[string]string) []string {
+	names := make([]string, 0, len(require))
+	for name := range require {
+		if isVirtualPackage(name) {
+			continue
+		}
+		if _, ok := replaced[name]; ok {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mergePackageInfo объединяет стабильные и dev-версии пакета
+func mergePackageInfo(info, dev *packagist.PackageInfo) *packagist.PackageInfo {
+	merged := &packagist.PackageInfo{Packages: make(map[string][]packagist.PackageVersion)}
+	for name, versions := range info.Packages {
+		merged.Packages[name] = append(merged.Packages[name], versions...)
+	}
+	for name, versions := range dev.Packages {
+		merged.Packages[name] = append(merged.Packages[name], versions...)
+	}
+	return merged
+}
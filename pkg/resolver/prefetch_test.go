@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xman12/go-composer/pkg/packagist"
+)
+
+// countingRepo отдает версии из versions, считает запросы и их максимальную параллельность
+type countingRepo struct {
+	versions map[string][]packagist.PackageVersion
+
+	mu          sync.Mutex
+	fetched     map[string]int
+	inFlight    int
+	maxInFlight int
+}
+
+func (r *countingRepo) GetPackage(name string) (*packagist.PackageInfo, error) {
+	r.mu.Lock()
+	r.fetched[name]++
+	r.inFlight++
+	if r.inFlight > r.maxInFlight {
+		r.maxInFlight = r.inFlight
+	}
+	r.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	r.mu.Lock()
+	r.inFlight--
+	r.mu.Unlock()
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{name: r.versions[name]}}, nil
+}
+
+func (r *countingRepo) GetDevPackage(name string) (*packagist.PackageInfo, error) {
+	return &packagist.PackageInfo{Packages: map[string][]packagist.PackageVersion{}}, nil
+}
+
+func TestPrefetchOnlyQueuedPackages(t *testing.T) {
+	repo := &countingRepo{
+		fetched: map[string]int{},
+		versions: map[string][]packagist.PackageVersion{
+			// Последняя версия a тянет c, но разрешается a 1.0, которой c не нужен
+			"acme/a": {
+				{Name: "acme/a", Version: "2.0.0", Require: map[string]string{"acme/c": "^1.0"}},
+				{Name: "acme/a", Version: "1.0.0", Require: map[string]string{"acme/b": "^1.0"}},
+			},
+			"acme/b": {{Name: "acme/b", Version: "1.0.0"}},
+			"acme/c": {{Name: "acme/c", Version: "1.0.0"}},
+		},
+	}
+	// Много корневых зависимостей, чтобы проверить ограничение параллельности
+	requirements := map[string]string{"acme/a": "^1.0"}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("acme/root%d", i)
+		repo.versions[name] = []packagist.PackageVersion{{Name: name, Version: "1.0.0"}}
+		requirements[name] = "^1.0"
+	}
+
+	r := NewResolver(repo)
+	r.SetConcurrency(3)
+	if _, err := r.Resolve(requirements); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.fetched["acme/c"] > 0 {
+		t.Error("acme/c was fetched, but the resolver never needed it")
+	}
+	for name, count := range repo.fetched {
+		if count > 1 {
+			t.Errorf("%s was fetched %d times", name, count)
+		}
+	}
+	if repo.maxInFlight > 3 {
+		t.Errorf("%d requests ran in parallel, want at most 3", repo.maxInFlight)
+	}
+}
@@ -16,9 +16,17 @@ type Package struct {
 	Info    *packagist.PackageVersion
 }
 
+// Repository - источник метаданных пакетов (*packagist.Client или repository.Manager)
+type Repository interface {
+	GetPackage(name string) (*packagist.PackageInfo, error)
+	GetDevPackage(name string) (*packagist.PackageInfo, error)
+}
+
 // Resolver разрешает зависимости пакетов
 type Resolver struct {
-	client      *packagist.Client
+	repo        Repository
+	concurrency int
+	metadata    *metadataStore
 	resolved    map[string]*Package
 	visited     map[string]bool
 	constraints map[string][]string // Все constraints для каждого пакета
@@ -26,9 +34,11 @@ type Resolver struct {
 }
 
 // NewResolver создает новый resolver
-func NewResolver(client *packagist.Client) *Resolver {
+func NewResolver(repo Repository) *Resolver {
 	return &Resolver{
-		client:      client,
+		repo:        repo,
+		concurrency: DefaultConcurrency,
+		metadata:    newMetadataStore(repo, DefaultConcurrency),
 		resolved:    make(map[string]*Package),
 		visited:     make(map[string]bool),
 		constraints: make(map[string][]string),
@@ -36,14 +46,36 @@ func NewResolver(client *packagist.Client) *Resolver {
 	}
 }
 
+// SetConcurrency ограничивает число параллельных запросов метаданных
+func (r *Resolver) SetConcurrency(concurrency int) {
+	r.concurrency = concurrency
+	r.metadata = newMetadataStore(r.repo, concurrency)
+}
+
+// SetRepository задает источник метаданных; загруженные ранее метаданные сбрасываются
+func (r *Resolver) SetRepository(repo Repository) {
+	r.repo = repo
+	r.metadata = newMetadataStore(repo, r.concurrency)
+}
+
+// Fork создает новый resolver с общим кешем метаданных,
+// чтобы повторное разрешение (например, с dev зависимостями) не запрашивало их снова
+func (r *Resolver) Fork() *Resolver {
+	fork := NewResolver(r.repo)
+	fork.concurrency = r.concurrency
+	fork.metadata = r.metadata
+	return fork
+}
+
 // Resolve разрешает все зависимости
 func (r *Resolver) Resolve(requirements map[string]string) (map[string]*Package, error) {
-	for name, constraint := range requirements {
-		// Пропускаем виртуальные и платформенные пакеты
-		if isVirtualPackage(name) {
-			continue
-		}
+	// Метаданные загружаются параллельно, а сам обход идет в порядке имен,
+	// чтобы результат не зависел от порядка ответов сети
+	names := dependencyNames(requirements, nil)
+	r.metadata.prefetch(names)
 
+	for _, name := range names {
+		constraint := requirements[name]
 		if err := r.resolvePackage(name, constraint); err != nil {
 			return nil, fmt.Errorf("failed to resolve %s: %w", name, err)
 		}
@@ -106,6 +138,11 @@ func (r *Resolver) resolvePackage(name, constraint string) error {
 	if r.visited[name] {
 		// Проверяем, подходит ли текущая выбранная версия под новый constraint
 		if pkg, ok := r.resolved[name]; ok {
+			// Ветки не сравниваются по semver - выбранная ветка остается
+			if isBranchVersion(pkg.Version) {
+				return nil
+			}
+
 			// Проверяем новый constraint
 			c, err := parseConstraint(constraint)
 			if err != nil {
@@ -120,7 +157,7 @@ func (r *Resolver) resolvePackage(name, constraint string) error {
 			// Если текущая версия не подходит - нужно переразрешить
 			if !c.Check(v) {
 				// Получаем информацию о пакете
-				info, err := r.client.GetPackage(name)
+				info, err := r.getPackage(name)
 				if err != nil {
 					return err
 				}
@@ -147,7 +184,7 @@ func (r *Resolver) resolvePackage(name, constraint string) error {
 	r.visited[name] = true
 
 	// Получаем информацию о пакете
-	info, err := r.client.GetPackage(name)
+	info, err := r.getPackage(name)
 	if err != nil {
 		return err
 	}
@@ -169,22 +206,18 @@ func (r *Resolver) resolvePackage(name, constraint string) error {
 	// Это важно для пакетов типа laravel/framework, которые предоставляют illuminate/* компоненты
 	r.processReplace(version)
 
-	// Рекурсивно разрешаем зависимости
-	if version.Require != nil {
-		for depName, depConstraint := range version.Require {
-			// Пропускаем виртуальные и платформенные пакеты
-			if isVirtualPackage(depName) {
-				continue
-			}
+	// Рекурсивно разрешаем зависимости (виртуальные и замененные через replace пропускаются)
+	deps := dependencyNames(version.Require, r.replaced)
+	r.metadata.prefetch(deps)
 
-			// Пропускаем пакеты, которые уже заменены через replace
-			if _, replaced := r.replaced[depName]; replaced {
-				continue
-			}
+	for _, depName := range deps {
+		// replace мог появиться при разрешении предыдущих зависимостей
+		if _, replaced := r.replaced[depName]; replaced {
+			continue
+		}
 
-			if err := r.resolvePackage(depName, depConstraint); err != nil {
-				return err
-			}
+		if err := r.resolvePackage(depName, version.Require[depName]); err != nil {
+			return err
 		}
 	}
 
@@ -372,6 +405,10 @@ func (r *Resolver) findVersionWithConstraints(packageVersions []packagist.Packag
 	}
 
 	if len(versions) == 0 {
+		// Пакет только с ветками (например, из path-репозитория) подходит под "*" и "@dev"
+		if version, ok := findWildcardBranch(packageVersions, constraints); ok {
+			return version, nil
+		}
 		return nil, fmt.Errorf("no valid versions found for package %s", name)
 	}
 
@@ -420,6 +457,11 @@ func (r *Resolver) findVersionSatisfyingAll(info *packagist.PackageInfo, name st
 		return nil, fmt.Errorf("no versions found for package %s", name)
 	}
 
+	// Constraint на ветку (dev-main, 2.x-dev) выбирает версию по точному имени
+	if version, ok := findBranchVersion(packageVersions, constraints); ok {
+		return version, nil
+	}
+
 	// Для критичных пакетов (carbon) разворачиваем OR constraints в отдельные варианты
 	// и пробуем найти совместимую версию
 	if name == "nesbot/carbon" {
@@ -458,6 +500,10 @@ func (r *Resolver) findVersionSatisfyingAll(info *packagist.PackageInfo, name st
 	}
 
 	if len(versions) == 0 {
+		// Пакет только с ветками (например, из path-репозитория) подходит под "*" и "@dev"
+		if version, ok := findWildcardBranch(packageVersions, constraints); ok {
+			return version, nil
+		}
 		return nil, fmt.Errorf("no valid versions found for package %s", name)
 	}
 
@@ -569,8 +615,11 @@ func parseConstraint(constraint string) (*semver.Constraints, error) {
 		constraint = ">=0.0.0"
 	}
 
-	// Нормализуем пробелы
-	constraint = strings.TrimSpace(constraint)
+	// Нормализуем пробелы и убираем флаги стабильности (^1.0@dev)
+	constraint = stripStabilityFlags(strings.TrimSpace(constraint))
+	if constraint == "" {
+		constraint = ">=0.0.0"
+	}
 
 	// Composer использует | для ИЛИ, а semver использует ||
 	// Сначала заменяем все варианты с пробелами
@@ -611,6 +660,102 @@ func parseConstraint(constraint string) (*semver.Constraints, error) {
 	return semver.NewConstraint(constraint)
 }
 
+// getPackage загружает метаданные пакета.
+// Dev-метаданные (~dev) запрашиваются, только если constraints требуют веток.
+func (r *Resolver) getPackage(name string) (*packagist.PackageInfo, error) {
+	return r.metadata.get(name, needsDevVersions(r.constraints[name]))
+}
+
+// needsDevVersions проверяет, ссылается ли хотя бы один constraint на ветку
+func needsDevVersions(constraints []string) bool {
+	for _, constraint := range constraints {
+		for _, part := range splitOr(constraint) {
+			if branchName(part) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findBranchVersion выбирает версию-ветку, если constraint ссылается на ветку.
+// Возвращается первая существующая ветка из constraints (в порядке их добавления);
+// совместимость с остальными constraints не проверяется: у веток нет номера версии,
+// а branch-alias здесь не учитывается.
+func findBranchVersion(packageVersions []packagist.PackageVersion, constraints []string) (*packagist.PackageVersion, bool) {
+	var branches []string
+	for _, constraint := range constraints {
+		for _, part := range splitOr(constraint) {
+			if branch := branchName(part); branch != "" {
+				branches = append(branches, branch)
+			}
+		}
+	}
+
+	for _, branch := range branches {
+		for i := range packageVersions {
+			if packageVersions[i].Version == branch {
+				return &packageVersions[i], true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// findWildcardBranch выбирает первую ветку, если все constraints допускают любую версию
+func findWildcardBranch(packageVersions []packagist.PackageVersion, constraints []string) (*packagist.PackageVersion, bool) {
+	for _, constraint := range constraints {
+		if c := stripStabilityFlags(constraint); c != "" && c != "*" {
+			return nil, false
+		}
+	}
+
+	for i := range packageVersions {
+		if isBranchVersion(packageVersions[i].Version) {
+			return &packageVersions[i], true
+		}
+	}
+	return nil, false
+}
+
+// branchName возвращает имя ветки из constraint вида "dev-main#abc as 1.0.x-dev"
+func branchName(constraint string) string {
+	constraint = strings.TrimSpace(constraint)
+	// Алиасы ("dev-main as 1.0.0") - используем реальную ветку
+	if idx := strings.Index(constraint, " as "); idx >= 0 {
+		constraint = strings.TrimSpace(constraint[:idx])
+	}
+	// Закрепленный коммит ("dev-main#abc123")
+	if idx := strings.Index(constraint, "#"); idx >= 0 {
+		constraint = constraint[:idx]
+	}
+	constraint = stripStabilityFlags(constraint)
+
+	if isBranchVersion(constraint) {
+		return constraint
+	}
+	return ""
+}
+
+// isBranchVersion проверяет, что версия является веткой (dev-main, 2.x-dev)
+func isBranchVersion(version string) bool {
+	return strings.HasPrefix(version, "dev-") || strings.HasSuffix(version, "-dev")
+}
+
+// splitOr разбивает constraint на OR-части ("|" и "||")
+func splitOr(constraint string) []string {
+	return strings.FieldsFunc(constraint, func(r rune) bool { return r == '|' })
+}
+
+// stripStabilityFlags убирает флаги стабильности (@dev, @beta, ...) из constraint
+func stripStabilityFlags(constraint string) string {
+	for _, flag := range []string{"@dev", "@alpha", "@beta", "@RC", "@rc", "@stable"} {
+		constraint = strings.ReplaceAll(constraint, flag, "")
+	}
+	return strings.TrimSpace(constraint)
+}
+
 // normalizeVersion нормализует версию для semver
 func normalizeVersion(version string) (*semver.Version, error) {
 	// Убираем префикс 'v' если есть
@@ -14,16 +14,30 @@ import (
 type Executor struct {
 	projectRoot  string
 	composerJSON *composer.ComposerJSON
+	vendorDir    string
+	binDir       string
 }
 
-// NewExecutor создает новый executor для скриптов
+// NewExecutor создает новый executor для скриптов.
+// vendor-dir и bin-dir берутся из config composer.json.
 func NewExecutor(projectRoot string, composerJSON *composer.ComposerJSON) *Executor {
+	config := composer.LoadConfig(composerJSON)
 	return &Executor{
 		projectRoot:  projectRoot,
 		composerJSON: composerJSON,
+		vendorDir:    projectPath(projectRoot, config.VendorDir),
+		binDir:       projectPath(projectRoot, config.BinDir),
 	}
 }
 
+// projectPath делает путь из config абсолютным относительно корня проекта
+func projectPath(projectRoot, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectRoot, path)
+}
+
 // Execute выполняет скрипты для указанного события
 func (e *Executor) Execute(event string) error {
 	if e.composerJSON == nil || e.composerJSON.Scripts == nil {
@@ -116,7 +130,7 @@ func (e *Executor) executePHPClassMethod(script string) error {
 	// Формат: ClassName::methodName
 	// Composer передает объект Event в метод, нужно создать mock
 
-	vendorAutoload := filepath.Join(e.projectRoot, "vendor", "autoload.php")
+	vendorAutoload := filepath.Join(e.vendorDir, "autoload.php")
 
 	// Проверяем существование autoload.php
 	if _, err := os.Stat(vendorAutoload); os.IsNotExist(err) {
@@ -126,7 +140,7 @@ func (e *Executor) executePHPClassMethod(script string) error {
 	}
 
 	// Создаем PHP код с полным mock Event класса
-	vendorPath := filepath.Join(e.projectRoot, "vendor")
+	vendorPath := e.vendorDir
 
 	phpCode := `
 		require '` + vendorAutoload + `';
@@ -225,10 +239,9 @@ func (e *Executor) executeShellCommand(script string) error {
 	cmd.Stderr = os.Stderr
 	cmd.Env = os.Environ()
 
-	// Добавляем путь к vendor/bin в PATH
-	vendorBin := filepath.Join(e.projectRoot, "vendor", "bin")
+	// Добавляем bin-dir (vendor/bin) в PATH
 	currentPath := os.Getenv("PATH")
-	cmd.Env = append(cmd.Env, fmt.Sprintf("PATH=%s:%s", vendorBin, currentPath))
+	cmd.Env = append(cmd.Env, fmt.Sprintf("PATH=%s%c%s", e.binDir, os.PathListSeparator, currentPath))
 
 	fmt.Printf("  ▶️  %s\n", script)
 	return cmd.Run()
@@ -0,0 +1,293 @@
+package vcs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/xman12/go-composer/pkg/cache"
+)
+
+// ErrNoMirror - в offline режиме локального зеркала репозитория нет
+var ErrNoMirror = errors.New("repository mirror is not cached")
+
+// mirrorLocks сериализует операции над одним зеркалом из разных горутин
+var mirrorLocks sync.Map
+
+// Ref - тег или ветка git репозитория
+type Ref struct {
+	Name   string // имя без refs/tags/ или refs/heads/
+	Commit string
+	IsTag  bool
+}
+
+// Git работает с git репозиторием через локальное bare-зеркало.
+// Зеркало создается git clone --mirror и обновляется git remote update,
+// поэтому повторные запуски и offline режим не требуют сети.
+type Git struct {
+	url     string
+	mirror  string
+	offline bool
+
+	syncOnce sync.Once
+	syncErr  error
+}
+
+// NewGit создает драйвер для url; зеркало хранится в кеше (или во временной директории)
+func NewGit(url string, c *cache.Cache, offline bool) *Git {
+	if c == nil {
+		c = cache.New(filepath.Join(os.TempDir(), "go-composer"))
+	}
+	return &Git{
+		url:     url,
+		mirror:  c.VCSPath(sourceURL(url)),
+		offline: offline,
+	}
+}
+
+// URL возвращает адрес репозитория
+func (g *Git) URL() string {
+	return g.url
+}
+
+// Sync создает или обновляет зеркало (один раз за время жизни драйвера).
+// В offline режиме используется существующее зеркало без обращения к сети.
+func (g *Git) Sync() error {
+	g.syncOnce.Do(func() {
+		unlock := g.lock()
+		defer unlock()
+		g.syncErr = g.sync()
+	})
+	return g.syncErr
+}
+
+// sync выполняет clone --mirror или remote update
+func (g *Git) sync() error {
+	if err := checkArgument("url", sourceURL(g.url)); err != nil {
+		return err
+	}
+
+	exists := isDir(g.mirror)
+
+	if g.offline {
+		if !exists {
+			return fmt.Errorf("cannot read %s in offline mode: %w", g.url, ErrNoMirror)
+		}
+		return nil
+	}
+
+	if exists {
+		if _, err := g.git(g.mirror, "remote", "update", "--prune"); err != nil {
+			return fmt.Errorf("failed to update %s: %w", g.url, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(g.mirror), 0755); err != nil {
+		return err
+	}
+
+	// Клонируем во временную директорию, чтобы прерванный clone не оставил битое зеркало
+	tmp, err := os.MkdirTemp(filepath.Dir(g.mirror), ".clone-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := g.git("", "clone", "--mirror", "--quiet", "--", sourceURL(g.url), tmp); err != nil {
+		return fmt.Errorf("failed to clone %s: %w", g.url, err)
+	}
+	return os.Rename(tmp, g.mirror)
+}
+
+// Refs возвращает теги и ветки репозитория. Для аннотированных тегов
+// Commit указывает на коммит, а не на объект тега.
+func (g *Git) Refs() ([]Ref, error) {
+	if err := g.Sync(); err != nil {
+		return nil, err
+	}
+
+	out, err := g.git(g.mirror, "for-each-ref", "--format=%(refname)%09%(objectname)%09%(*objectname)", "refs/tags", "refs/heads")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs of %s: %w", g.url, err)
+	}
+
+	var refs []Ref
+	// У легковесных тегов и веток %(*objectname) пустой, поэтому строка заканчивается
+	// табуляцией: обрезать можно только переводы строк
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		ref := Ref{Commit: fields[1]}
+		if fields[2] != "" {
+			ref.Commit = fields[2]
+		}
+
+		switch {
+		case strings.HasPrefix(fields[0], "refs/tags/"):
+			ref.Name, ref.IsTag = strings.TrimPrefix(fields[0], "refs/tags/"), true
+		case strings.HasPrefix(fields[0], "refs/heads/"):
+			ref.Name = strings.TrimPrefix(fields[0], "refs/heads/")
+		default:
+			continue
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// DefaultBranch возвращает ветку, на которую указывает HEAD зеркала
+func (g *Git) DefaultBranch() (string, error) {
+	if err := g.Sync(); err != nil {
+		return "", err
+	}
+
+	out, err := g.git(g.mirror, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to read default branch of %s: %w", g.url, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ReadFile возвращает содержимое файла в указанном коммите
+func (g *Git) ReadFile(commit, path string) ([]byte, error) {
+	if err := checkArgument("reference", commit); err != nil {
+		return nil, err
+	}
+	out, err := g.git(g.mirror, "show", commit+":"+path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// CommitTime возвращает время коммита в формате ISO 8601
+func (g *Git) CommitTime(commit string) (string, error) {
+	if err := checkArgument("reference", commit); err != nil {
+		return "", err
+	}
+	out, err := g.git(g.mirror, "log", "-1", "--format=%cI", commit)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Checkout клонирует репозиторий из зеркала в dest и переключается на reference.
+// Зеркало обновляется, только если в нем нет нужного коммита.
+func (g *Git) Checkout(dest, reference string) error {
+	if err := checkArgument("reference", reference); err != nil {
+		return err
+	}
+	if err := checkArgument("url", sourceURL(g.url)); err != nil {
+		return err
+	}
+
+	if !g.hasCommit(reference) {
+		if err := g.Sync(); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	if _, err := g.git("", "clone", "--quiet", "--no-checkout", "--", g.mirror, dest); err != nil {
+		return fmt.Errorf("failed to clone %s: %w", g.url, err)
+	}
+	if _, err := g.git(dest, "checkout", "--quiet", "--detach", reference); err != nil {
+		// reference может быть именем ветки (inline package), а ветки клона есть только в origin/
+		if _, branchErr := g.git(dest, "checkout", "--quiet", "--detach", "origin/"+reference); branchErr != nil {
+			return fmt.Errorf("failed to checkout %s of %s: %w", reference, g.url, err)
+		}
+	}
+
+	// origin указывает на исходный репозиторий, а не на зеркало в кеше
+	if _, err := g.git(dest, "remote", "set-url", "origin", "--", sourceURL(g.url)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// HasMirror проверяет, что зеркало репозитория уже есть в кеше
+func (g *Git) HasMirror() bool {
+	return isDir(g.mirror)
+}
+
+// hasCommit проверяет наличие коммита в зеркале
+func (g *Git) hasCommit(reference string) bool {
+	if !isDir(g.mirror) || checkArgument("reference", reference) != nil {
+		return false
+	}
+	_, err := g.git(g.mirror, "cat-file", "-e", reference+"^{commit}")
+	return err == nil
+}
+
+// lock блокирует зеркало для текущей горутины
+func (g *Git) lock() func() {
+	value, _ := mirrorLocks.LoadOrStore(g.mirror, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// git выполняет команду git в директории dir и возвращает stdout
+func (g *Git) git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Не даем git ждать ввода пароля в терминале
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return stdout.String(), nil
+}
+
+// checkArgument отклоняет url и reference, начинающиеся с "-": они приходят из
+// метаданных пакетов и lock файла, и git принял бы их за опции (--upload-pack=...)
+func checkArgument(kind, value string) error {
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("invalid git %s %q: must not start with \"-\"", kind, value)
+	}
+	return nil
+}
+
+// sourceURL делает локальные пути абсолютными, URL и scp-адреса оставляет как есть
+func sourceURL(url string) string {
+	if strings.Contains(url, "://") || strings.Contains(url, "@") {
+		return url
+	}
+	if abs, err := filepath.Abs(url); err == nil {
+		return abs
+	}
+	return url
+}
+
+// isDir проверяет существование директории
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
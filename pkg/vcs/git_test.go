@@ -0,0 +1,108 @@
+package vcs
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/xman12/go-composer/pkg/cache"
+)
+
+// newTestRepo создает git репозиторий с одним коммитом и указанными тегами
+func newTestRepo(t *testing.T, lightweight, annotated []string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	run("init", "--quiet")
+	run("commit", "--quiet", "--allow-empty", "-m", "initial")
+	for _, tag := range lightweight {
+		run("tag", tag)
+	}
+	for _, tag := range annotated {
+		run("tag", "-a", "-m", tag, tag)
+	}
+	return dir
+}
+
+func TestRefs(t *testing.T) {
+	tests := []struct {
+		name        string
+		lightweight []string
+		annotated   []string
+		want        []string
+	}{
+		{"single lightweight tag", []string{"v1.0.0"}, nil, []string{"main", "v1.0.0"}},
+		{"lightweight tags", []string{"v1.0.0", "v1.1.0"}, nil, []string{"main", "v1.0.0", "v1.1.0"}},
+		{"annotated before lightweight", []string{"v1.1.0"}, []string{"v1.0.0"}, []string{"main", "v1.0.0", "v1.1.0"}},
+		{"lightweight before annotated", []string{"v1.0.0"}, []string{"v1.1.0"}, []string{"main", "v1.0.0", "v1.1.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t, tt.lightweight, tt.annotated)
+			g := NewGit(repo, cache.New(t.TempDir()), false)
+
+			refs, err := g.Refs()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			commit := ""
+			for _, ref := range refs {
+				names = append(names, ref.Name)
+				if commit == "" {
+					commit = ref.Commit
+				}
+				// Аннотированные теги должны указывать на коммит, а не на объект тега
+				if ref.Commit != commit {
+					t.Errorf("ref %s points to %s, want %s", ref.Name, ref.Commit, commit)
+				}
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("refs = %v, want %v", names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Fatalf("refs = %v, want %v", names, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRejectsOptionLikeArguments(t *testing.T) {
+	repo := newTestRepo(t, []string{"v1.0.0"}, nil)
+	c := cache.New(t.TempDir())
+
+	if _, err := NewGit("--upload-pack=touch /tmp/pwned@x", c, false).Refs(); err == nil {
+		t.Error("Refs accepted an url starting with \"-\"")
+	}
+
+	g := NewGit(repo, c, false)
+	if err := g.Checkout(filepath.Join(t.TempDir(), "dest"), "--orphan=x"); err == nil {
+		t.Error("Checkout accepted a reference starting with \"-\"")
+	}
+	if _, err := g.CommitTime("--output=/tmp/pwned"); err == nil {
+		t.Error("CommitTime accepted a reference starting with \"-\"")
+	}
+	if _, err := g.ReadFile("--output=/tmp/pwned", "composer.json"); err == nil {
+		t.Error("ReadFile accepted a reference starting with \"-\"")
+	}
+	if err := g.Checkout(filepath.Join(t.TempDir(), "dest"), "v1.0.0"); err != nil {
+		t.Errorf("Checkout(v1.0.0) = %v", err)
+	}
+}